@@ -0,0 +1,97 @@
+package csvhandler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+// OpenAppend opens the named file to append records with the given header, the file is created if needed.
+//
+// Dialect is the format of the file, for instance as detected by Sniff. A zero Dialect means comma separated values
+// with LF line terminators.
+// If the file is not empty, its header must match the given one, otherwise ErrHeaderMismatch is returned,
+// and the header line is not written again. Otherwise the header line is written right away.
+//
+// The header is checked and written under an exclusive lock of the file (flock, or LockFileEx on Windows),
+// so that it is written only once by concurrent callers, possibly from different processes.
+// Locks are advisory: other programs writing to the file are not coordinated. On platforms without file locks,
+// such as Solaris or Plan 9, the header is only guaranteed once for sequential callers.
+//
+// The Writer must be closed with Close once done.
+func OpenAppend(path string, dialect Dialect, header ...string) (*Writer, error) {
+	if dialect.Comma == 0 {
+		dialect.Comma = ','
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := newAppendWriter(f, dialect, header)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+func newAppendWriter(f *os.File, dialect Dialect, header []string) (*Writer, error) {
+	unlock, err := lockFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot lock file: %w", err)
+	}
+	defer unlock()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size > 0 {
+		cr := csv.NewReader(decodeBOM(io.NewSectionReader(f, 0, size)))
+		dialect.ConfigureReader(cr)
+		actual, err := cr.Read()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if !equalHeaders(actual, header) {
+			return nil, ErrHeaderMismatch{expected: header, actual: actual}
+		}
+
+		// Terminate the last line if needed so that records are not appended to it
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			return nil, err
+		}
+		if last[0] != '\n' {
+			terminator := dialect.LineTerminator
+			if terminator == "" {
+				terminator = "\n"
+			}
+			if _, err := f.WriteString(terminator); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cw := csv.NewWriter(f)
+	dialect.ConfigureWriter(cw)
+	writer, err := NewWriter(cw, header...)
+	if err != nil {
+		return nil, err
+	}
+	writer.closer = f
+	writer.headerDone = size > 0
+	writer.AutoHeader = true
+	if size == 0 {
+		// Write the header while holding the lock so that concurrent callers find it
+		if err := writer.writeHeader(); err != nil {
+			return nil, err
+		}
+		if err := writer.flush(); err != nil {
+			return nil, err
+		}
+	}
+	return writer, nil
+}
@@ -0,0 +1,24 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package csvhandler
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on f, waiting for it if needed, and returns the function releasing it.
+func lockFile(f *os.File) (func() error, error) {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		}, nil
+	}
+}
@@ -0,0 +1,158 @@
+package csvhandler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAppend(t *testing.T) {
+	testcases := map[string]struct {
+		content  *string // nil if the file does not exist
+		dialect  Dialect
+		expected string
+		errType  interface{}
+	}{
+		"new file": {
+			expected: "first_name,age\nHolly,27\n",
+		},
+		"empty file": {
+			content:  strPtr(""),
+			expected: "first_name,age\nHolly,27\n",
+		},
+		"existing file": {
+			content:  strPtr("first_name,age\nJohn,20\n"),
+			expected: "first_name,age\nJohn,20\nHolly,27\n",
+		},
+		"header only": {
+			content:  strPtr("first_name,age"),
+			expected: "first_name,age\nHolly,27\n",
+		},
+		"missing line terminator": {
+			content:  strPtr("first_name;age\r\nJohn;20"),
+			dialect:  Dialect{Comma: ';', LineTerminator: "\r\n"},
+			expected: "first_name;age\r\nJohn;20\r\nHolly;27\r\n",
+		},
+		"byte order mark": {
+			content:  strPtr("\ufefffirst_name,age\n"),
+			expected: "\ufefffirst_name,age\nHolly,27\n",
+		},
+		"header mismatch": {
+			content: strPtr("first_name,last_name\nJohn,Smith\n"),
+			errType: &ErrHeaderMismatch{},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.csv")
+			if tc.content != nil {
+				require.NoError(t, os.WriteFile(path, []byte(*tc.content), 0644))
+			}
+
+			w, err := OpenAppend(path, tc.dialect, "first_name", "age")
+			if tc.errType != nil {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, tc.errType))
+				return
+			}
+			require.NoError(t, err)
+			r := NewRecord()
+			r.Set("first_name", "Holly")
+			r.Set("age", 27)
+			require.NoError(t, w.Write(r))
+			require.NoError(t, w.WriteHeader())
+			require.NoError(t, w.Close())
+
+			b, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(b))
+		})
+	}
+}
+
+func TestOpenAppendTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	for _, name := range []string{"Holly", "John"} {
+		w, err := OpenAppend(path, Dialect{}, "first_name")
+		require.NoError(t, err)
+		r := NewRecord()
+		r.Set("first_name", name)
+		require.NoError(t, w.Write(r))
+		require.NoError(t, w.Close())
+	}
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first_name\nHolly\nJohn\n", string(b))
+}
+
+func TestOpenAppendConcurrent(t *testing.T) {
+	for n, content := range map[string]*string{"new file": nil, "empty file": strPtr("")} {
+		t.Run(n, func(t *testing.T) {
+			testOpenAppendConcurrent(t, content)
+		})
+	}
+}
+
+func testOpenAppendConcurrent(t *testing.T, content *string) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if content != nil {
+		require.NoError(t, os.WriteFile(path, []byte(*content), 0644))
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, err := OpenAppend(path, Dialect{}, "job", "status")
+			if err != nil {
+				errs <- err
+				return
+			}
+			r := NewRecord()
+			r.Set("job", "a")
+			r.Set("status", "ok")
+			if err := w.Write(r); err != nil {
+				errs <- err
+			}
+			errs <- w.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "job,status\n"+strings.Repeat("a,ok\n", 10), string(b))
+}
+
+func TestOpenAppendHeaderOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	w, err := OpenAppend(path, Dialect{}, "first_name")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first_name\n", string(b))
+}
+
+func TestOpenAppendError(t *testing.T) {
+	_, err := OpenAppend(filepath.Join(t.TempDir(), "missing", "out.csv"), Dialect{}, "first_name")
+	assert.Error(t, err)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
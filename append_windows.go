@@ -0,0 +1,30 @@
+package csvhandler
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// lockfileExclusiveLock is the LOCKFILE_EXCLUSIVE_LOCK flag of LockFileEx.
+const lockfileExclusiveLock = 0x2
+
+// lockFile takes an exclusive lock on the first byte of f, waiting for it if needed, and returns the function releasing it.
+func lockFile(f *os.File) (func() error, error) {
+	ol := new(syscall.Overlapped)
+	if r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(ol))); r == 0 {
+		return nil, err
+	}
+	return func() error {
+		if r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(ol))); r == 0 {
+			return err
+		}
+		return nil
+	}, nil
+}
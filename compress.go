@@ -0,0 +1,253 @@
+package csvhandler
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	tarMagic   = []byte("ustar")
+)
+
+// tarMagicOffset is the offset of the magic bytes within a tar header.
+const tarMagicOffset = 257
+
+// Open opens the named file and creates a new Reader from it, see NewReaderFrom.
+// The Reader must be closed with Close once done.
+func Open(path string, opts ...ReaderOption) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := NewReaderFrom(f, opts...)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	reader.closers = append([]io.Closer{f}, reader.closers...)
+	return reader, nil
+}
+
+// NewReaderFrom creates a new Reader from the given reader, transparently decompressing its content.
+//
+// Gzip, zlib and bzip2 compressions are detected with their magic bytes, as well as tar archives (possibly compressed).
+// The regular files of a tar archive are read one after the other as a single input,
+// the header of each file must then match the header of the first one, otherwise ErrHeaderMismatch is returned.
+// Use EachMember to read each file of the archive with its own Reader.
+//
+// Options are the same as for NewReaderWithOptions, WithCSVReader can be used to configure the created `encoding/csv.Reader`.
+// Content is decoded according to its byte order mark or the WithEncoding option.
+// The Reader should be closed with Close once done to release the decompressors.
+func NewReaderFrom(r io.Reader, opts ...ReaderOption) (*Reader, error) {
+	src, tr, closers, err := openStream(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := applyOptions(opts)
+	var next func() (*csv.Reader, string, error)
+	if tr != nil {
+		next = func() (*csv.Reader, string, error) {
+			name, err := nextTarMember(tr)
+			if err != nil {
+				return nil, "", err
+			}
+			return csv.NewReader(reader.decode(tr)), name, nil
+		}
+		if _, err := nextTarMember(tr); err != nil {
+			closeAll(closers)
+			return nil, err
+		}
+		src = tr
+	}
+
+	reader, err = newReader(csv.NewReader(reader.decode(src)), reader)
+	if err != nil {
+		closeAll(closers)
+		return nil, err
+	}
+	reader.closers = closers
+	reader.nextMember = next
+	return reader, nil
+}
+
+// EachMember creates a new Reader for each regular file of the given tar archive and calls fn with its name.
+// Compression is detected as with NewReaderFrom. If r is not a tar archive, fn is called once with an empty name.
+//
+// It stops at the first error returned by NewReaderWithOptions or fn.
+func EachMember(r io.Reader, fn func(name string, reader *Reader) error, opts ...ReaderOption) error {
+	src, tr, closers, err := openStream(r)
+	if err != nil {
+		return err
+	}
+	defer closeAll(closers)
+
+	if tr == nil {
+		reader := applyOptions(opts)
+		reader, err := newReader(csv.NewReader(reader.decode(src)), reader)
+		if err != nil {
+			return err
+		}
+		return fn("", reader)
+	}
+
+	for {
+		name, err := nextTarMember(tr)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		reader := applyOptions(opts)
+		reader, err = newReader(csv.NewReader(reader.decode(tr)), reader)
+		if err != nil {
+			return fmt.Errorf("cannot read member '%s': %w", name, err)
+		}
+		if err := fn(name, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// Close releases the resources opened by Open or NewReaderFrom, such as the file or the decompressors.
+// It does nothing for Readers created from an `encoding/csv.Reader`.
+func (r *Reader) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := closeAll(r.closers)
+	r.closers = nil
+	return err
+}
+
+// nextReader switches to the next member of the input, checking its header matches.
+// It returns io.EOF if there are no more members.
+// Caller must hold the mutex.
+func (r *Reader) nextReader() error {
+	cr, name, err := r.nextMember()
+	if err != nil {
+		return err
+	}
+	r.configureCSVReader(cr)
+	if r.headerRow {
+		header, err := cr.Read()
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("cannot read member '%s': %w", name, err)
+		}
+		if err == nil && !equalHeaders(header, r.rawHeader) {
+			return fmt.Errorf("cannot read member '%s': %w", name, ErrHeaderMismatch{expected: r.rawHeader, actual: header})
+		}
+	}
+	r.reader = cr
+	return nil
+}
+
+// openStream decompresses r if needed and detects whether the content is a tar archive.
+func openStream(r io.Reader) (io.Reader, *tar.Reader, []io.Closer, error) {
+	src, closer, err := decompress(bufio.NewReader(r))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var closers []io.Closer
+	if closer != nil {
+		closers = append(closers, closer)
+	}
+
+	br, ok := src.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(src)
+	}
+	if isTar(br) {
+		return br, tar.NewReader(br), closers, nil
+	}
+	return br, nil, closers, nil
+}
+
+// decompress returns a reader of the decompressed content of br if compressed with gzip, zlib or bzip2, br otherwise.
+// The returned closer, if not nil, must be closed once done.
+func decompress(br *bufio.Reader) (io.Reader, io.Closer, error) {
+	magic, _ := br.Peek(len(bzip2Magic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gr, gr, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return bzip2.NewReader(br), nil, nil
+	case isZlib(magic):
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr, nil
+	}
+	return br, nil, nil
+}
+
+// isZlib returns whether the given bytes are a zlib header using deflate with the usual compression levels.
+func isZlib(magic []byte) bool {
+	if len(magic) < 2 || magic[0] != 0x78 {
+		return false
+	}
+	switch magic[1] {
+	case 0x01, 0x5e, 0x9c, 0xda:
+		return true
+	}
+	return false
+}
+
+// isTar returns whether br starts with a tar header.
+func isTar(br *bufio.Reader) bool {
+	header, _ := br.Peek(tarMagicOffset + len(tarMagic))
+	return len(header) == tarMagicOffset+len(tarMagic) && bytes.Equal(header[tarMagicOffset:], tarMagic)
+}
+
+// nextTarMember advances to the next regular file of the archive and returns its name.
+func nextTarMember(tr *tar.Reader) (string, error) {
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			return "", err
+		}
+		if h.Typeflag == tar.TypeReg {
+			return h.Name, nil
+		}
+	}
+}
+
+// closeAll closes the given closers in reverse order and returns the first error.
+func closeAll(closers []io.Closer) error {
+	var err error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if e := closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// equalHeaders returns whether both headers have the same columns in the same order.
+func equalHeaders(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package csvhandler
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tstTar returns a tar archive with the given files, a directory is also added.
+func tstTar(t *testing.T, files ...[2]string) []byte {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f[0], Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f[1]))}))
+		_, err := tw.Write([]byte(f[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return b.Bytes()
+}
+
+func tstGzip(t *testing.T, data []byte) []byte {
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	_, err := gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return b.Bytes()
+}
+
+func tstZlib(t *testing.T, data []byte) []byte {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	_, err := zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func TestNewReaderFrom(t *testing.T) {
+	data := []byte("first_name;age\nHolly;27\nGiacobo;18\n")
+
+	testcases := map[string]struct {
+		data    []byte
+		names   []string
+		err     bool
+		errType interface{}
+	}{
+		"plain": {
+			data:  data,
+			names: []string{"Holly", "Giacobo"},
+		},
+		"gzip": {
+			data:  tstGzip(t, data),
+			names: []string{"Holly", "Giacobo"},
+		},
+		"zlib": {
+			data:  tstZlib(t, data),
+			names: []string{"Holly", "Giacobo"},
+		},
+		"tar": {
+			data:  tstTar(t, [2]string{"a.csv", string(data)}, [2]string{"b.csv", "first_name;age\nAubrie;32\n"}),
+			names: []string{"Holly", "Giacobo", "Aubrie"},
+		},
+		"gzip tar with empty member": {
+			data:  tstGzip(t, tstTar(t, [2]string{"a.csv", string(data)}, [2]string{"empty.csv", ""}, [2]string{"b.csv", "first_name;age\nAubrie;32"})),
+			names: []string{"Holly", "Giacobo", "Aubrie"},
+		},
+		"tar header mismatch": {
+			data:    tstTar(t, [2]string{"a.csv", string(data)}, [2]string{"b.csv", "name;age\nAubrie;32\n"}),
+			err:     true,
+			errType: &ErrHeaderMismatch{},
+		},
+		"empty tar": {
+			data: tstTar(t),
+			err:  true,
+		},
+		"corrupted gzip": {
+			data: append([]byte{0x1f, 0x8b}, data...),
+			err:  true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReaderFrom(bytes.NewReader(tc.data), WithCSVReader(func(r *csv.Reader) {
+				r.Comma = ';'
+			}))
+			if err == nil {
+				defer reader.Close()
+				var records []*Record
+				records, err = reader.ReadAll()
+				if !tc.err {
+					require.NoError(t, err)
+					var names []string
+					for _, r := range records {
+						n, err := r.Get("first_name")
+						require.NoError(t, err)
+						names = append(names, n)
+					}
+					assert.Equal(t, tc.names, names)
+				}
+			}
+			if tc.err {
+				require.Error(t, err)
+				if tc.errType != nil {
+					assert.True(t, errors.As(err, tc.errType))
+				}
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	testcases := map[string]struct {
+		filename string
+		err      bool
+	}{
+		"regular": {
+			filename: "regular.csv",
+		},
+		"tgz": {
+			filename: "compressed.tgz",
+		},
+		"bzip2": {
+			filename: "regular.csv.bz2",
+		},
+		"missing": {
+			filename: "missing.csv",
+			err:      true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := Open(filepath.Join("tstdata", tc.filename))
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			records, err := reader.ReadAll()
+			require.NoError(t, err)
+			require.Len(t, records, 5)
+			n, err := records[4].Get("first_name")
+			require.NoError(t, err)
+			assert.Equal(t, "Jasmine", n)
+			assert.NoError(t, reader.Close())
+		})
+	}
+}
+
+func TestEachMember(t *testing.T) {
+	testcases := map[string]struct {
+		data     []byte
+		expected map[string][]string
+		err      bool
+	}{
+		"tar": {
+			data: tstGzip(t, tstTar(t, [2]string{"a.csv", "first_name\nHolly\n"}, [2]string{"b.csv", "name\nAubrie\nJasmine\n"})),
+			expected: map[string][]string{
+				"a.csv": {"first_name"},
+				"b.csv": {"name"},
+			},
+		},
+		"plain": {
+			data: []byte("first_name\nHolly\n"),
+			expected: map[string][]string{
+				"": {"first_name"},
+			},
+		},
+		"empty member": {
+			data: tstTar(t, [2]string{"a.csv", ""}),
+			err:  true,
+		},
+		"corrupted": {
+			data: []byte{0x1f, 0x8b, 0x00},
+			err:  true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			headers := make(map[string][]string)
+			err := EachMember(bytes.NewReader(tc.data), func(name string, reader *Reader) error {
+				headers[name] = reader.header
+				_, err := reader.ReadAll()
+				return err
+			})
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, headers)
+			}
+		})
+	}
+}
+
+func TestEachMemberCallbackError(t *testing.T) {
+	data := tstTar(t, [2]string{"a.csv", "first_name\nHolly\n"}, [2]string{"b.csv", "first_name\nAubrie\n"})
+	calls := 0
+	err := EachMember(bytes.NewReader(data), func(name string, reader *Reader) error {
+		calls++
+		return io.ErrUnexpectedEOF
+	})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,80 @@
+package csvhandler
+
+import (
+	"context"
+	"io"
+)
+
+// ReadContext reads one record as Read does, unless the context is done.
+// In that case, ErrCanceled wrapping the context error is returned.
+//
+// The context is checked before reading, a read blocked on the underlying reader is not interrupted.
+func (r *Reader) ReadContext(ctx context.Context) (*Record, error) {
+	if err := r.contextErr(ctx); err != nil {
+		return nil, err
+	}
+	return r.Read()
+}
+
+// ReadAllContext reads all the remaining records as ReadAll does.
+// It stops and returns ErrCanceled wrapping the context error as soon as the context is done.
+//
+// The context is checked before reading each record.
+func (r *Reader) ReadAllContext(ctx context.Context) ([]*Record, error) {
+	var records []*Record
+	for {
+		if err := r.contextErr(ctx); err != nil {
+			return nil, err
+		}
+		record, _, err := r.next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// contextErr returns ErrCanceled if the context is done.
+func (r *Reader) contextErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		return ErrCanceled{row: r.count, err: err}
+	}
+	return nil
+}
+
+// WriteContext writes the given record as Write does, unless the context is done.
+// In that case, ErrCanceled wrapping the context error is returned.
+func (w *Writer) WriteContext(ctx context.Context, r *Record) error {
+	if err := w.contextErr(ctx); err != nil {
+		return err
+	}
+	return w.Write(r)
+}
+
+// WriteAllContext writes all the given records as WriteAll does.
+// It stops and returns ErrCanceled wrapping the context error as soon as the context is done,
+// the records written so far are flushed.
+func (w *Writer) WriteAllContext(ctx context.Context, r []*Record) error {
+	for _, record := range r {
+		if err := w.WriteContext(ctx, record); err != nil {
+			w.Flush()
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// contextErr returns ErrCanceled if the context is done.
+func (w *Writer) contextErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		return ErrCanceled{row: w.count, err: err}
+	}
+	return nil
+}
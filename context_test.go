@@ -0,0 +1,123 @@
+package csvhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tstCancelAfter returns a context canceled once fn has been called n times.
+func tstCancelAfter(n int) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	return ctx, func() {
+		count++
+		if count == n {
+			cancel()
+		}
+	}
+}
+
+func TestReadContext(t *testing.T) {
+	f, err := os.Open(filepath.Join("tstdata", "regular.csv"))
+	require.NoError(t, err)
+	defer f.Close()
+	reader, err := NewReader(csv.NewReader(f))
+	require.NoError(t, err)
+
+	ctx, tick := tstCancelAfter(2)
+	for i := 0; i < 2; i++ {
+		_, err := reader.ReadContext(ctx)
+		require.NoError(t, err)
+		tick()
+	}
+	_, err = reader.ReadContext(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+	var cerr ErrCanceled
+	require.True(t, errors.As(err, &cerr))
+	assert.Equal(t, 2, cerr.Row())
+}
+
+func TestReadAllContext(t *testing.T) {
+	testcases := map[string]struct {
+		ctx func() context.Context
+		err error
+	}{
+		"regular": {
+			ctx: context.Background,
+		},
+		"canceled": {
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			err: context.Canceled,
+		},
+		"deadline exceeded": {
+			ctx: func() context.Context {
+				ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+				defer cancel()
+				return ctx
+			},
+			err: context.DeadlineExceeded,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("tstdata", "regular.csv"))
+			require.NoError(t, err)
+			defer f.Close()
+			reader, err := NewReader(csv.NewReader(f))
+			require.NoError(t, err)
+
+			records, err := reader.ReadAllContext(tc.ctx())
+			if tc.err != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tc.err))
+				assert.Nil(t, records)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, records, 5)
+			}
+		})
+	}
+}
+
+func TestWriteContext(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(csv.NewWriter(&b), "name")
+	require.NoError(t, err)
+	w.FlushRows = 0
+
+	var records []*Record
+	for _, name := range []string{"Holly", "Giacobo", "Aubrie"} {
+		r := NewRecord()
+		r.Set("name", name)
+		records = append(records, r)
+	}
+	require.NoError(t, w.WriteAllContext(context.Background(), records))
+	assert.Equal(t, "Holly\nGiacobo\nAubrie\n", b.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, w.WriteContext(ctx, records[0]))
+	cancel()
+	err = w.WriteAllContext(ctx, records)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+	var cerr ErrCanceled
+	require.True(t, errors.As(err, &cerr))
+	assert.Equal(t, 4, cerr.Row())
+	assert.Contains(t, err.Error(), "stopped after 4 records")
+	assert.Equal(t, "Holly\nGiacobo\nAubrie\nHolly\n", b.String())
+}
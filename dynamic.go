@@ -0,0 +1,213 @@
+package csvhandler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// defaultHeaderWindow is the number of records used to build the header when DynamicOptions.Window is not set.
+const defaultHeaderWindow = 1000
+
+// HeaderMode defines how a dynamic Writer builds its header from the keys of the records, see NewDynamicWriter.
+type HeaderMode int
+
+const (
+	// HeaderFirstRecord uses the keys of the first record, in the same order.
+	HeaderFirstRecord HeaderMode = iota
+	// HeaderUnion uses the keys of all the records of the window, in order of appearance.
+	HeaderUnion
+	// HeaderSortedUnion uses the keys of all the records of the window, sorted.
+	HeaderSortedUnion
+)
+
+// DynamicOptions configures the Writer created by NewDynamicWriter.
+type DynamicOptions struct {
+	Mode   HeaderMode // How the header is built
+	Window int        // Number of records kept in memory to build the header, 1000 if not set
+	Spool  bool       // Whether all the records are spooled to a temporary file to build the header, instead of a window
+}
+
+// dynamicHeader holds the records received while the header of a dynamic Writer is not built.
+type dynamicHeader struct {
+	opts    DynamicOptions
+	keys    []string
+	seen    map[string]struct{}
+	records []*Record // Records of the window
+	spool   *os.File  // Temporary file holding the formatted records, nil if not spooling
+	spooled *csv.Writer
+}
+
+// NewDynamicWriter creates a new Writer whose header is built from the keys of the records it receives.
+//
+// Records are kept in memory until the window is full, or Flush or Close are called,
+// the header is then built according to the mode and written before the pending records.
+// With Spool, the records are formatted and written to a temporary file instead,
+// so the header is built from all the records when Close is called.
+// Keys of the records written once the header is built and not part of it are ignored.
+//
+// The Writer must be closed with Close once done.
+func NewDynamicWriter(w *csv.Writer, opts DynamicOptions) *Writer {
+	if opts.Window <= 0 {
+		opts.Window = defaultHeaderWindow
+	}
+	if opts.Mode == HeaderFirstRecord {
+		opts.Window = 1
+	}
+	writer, _ := NewWriter(w)
+	writer.AutoHeader = true
+	writer.dynamic = &dynamicHeader{
+		opts: opts,
+		seen: make(map[string]struct{}),
+	}
+	return writer
+}
+
+// writeDynamic keeps the given record until the header is built. Caller must hold the mutex.
+func (w *Writer) writeDynamic(r *Record) error {
+	d := w.dynamic
+	if d.opts.Mode != HeaderFirstRecord || len(d.keys) == 0 {
+		d.addKeys(r)
+	}
+
+	if d.opts.Spool {
+		return w.spoolRecord(r)
+	}
+	c := NewRecord()
+	for _, k := range r.keys {
+		c.put(k, r.fields[k])
+	}
+	d.records = append(d.records, c)
+	if len(d.records) < d.opts.Window {
+		return nil
+	}
+	return w.buildHeader()
+}
+
+// addKeys adds to the header the keys of the given record not seen yet.
+func (d *dynamicHeader) addKeys(r *Record) {
+	for _, k := range r.keys {
+		if _, seen := d.seen[k]; !seen {
+			d.keys = append(d.keys, k)
+			d.seen[k] = struct{}{}
+		}
+	}
+}
+
+// spoolRecord writes the keys of the given record with their formatted value to the temporary file.
+// Each record is written as a line of key, value and quote flag triplets.
+func (w *Writer) spoolRecord(r *Record) error {
+	d := w.dynamic
+	if d.spool == nil {
+		f, err := os.CreateTemp("", "csvhandler-*.csv")
+		if err != nil {
+			return fmt.Errorf("cannot create spool file: %w", err)
+		}
+		d.spool = f
+		d.spooled = csv.NewWriter(f)
+	}
+
+	line := make([]string, 0, 3*len(r.keys))
+	for _, k := range r.keys {
+		value, quote, err := w.formatField(r, k)
+		if err != nil {
+			return err
+		}
+		q := ""
+		if quote {
+			q = "q"
+		}
+		line = append(line, k, value, q)
+	}
+	if err := d.spooled.Write(line); err != nil {
+		return fmt.Errorf("cannot spool record: %w", err)
+	}
+	return nil
+}
+
+// buildHeader builds the header from the keys received so far,
+// then writes the header and the pending records. Caller must hold the mutex.
+func (w *Writer) buildHeader() error {
+	d := w.dynamic
+	w.dynamic = nil
+	w.header = d.keys
+	if d.opts.Mode == HeaderSortedUnion {
+		sort.Strings(w.header)
+	}
+	for _, k := range w.header {
+		w.columns[k] = struct{}{}
+	}
+
+	if d.spool != nil {
+		defer os.Remove(d.spool.Name())
+		defer d.spool.Close()
+		return w.writeSpool(d)
+	}
+	for _, r := range d.records {
+		if err := w.write(r); err != nil {
+			return err
+		}
+	}
+	if len(d.records) == 0 {
+		return w.writeHeader()
+	}
+	return nil
+}
+
+// writeSpool writes the header and the records of the temporary file. Caller must hold the mutex.
+func (w *Writer) writeSpool(d *dynamicHeader) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	d.spooled.Flush()
+	if err := d.spooled.Error(); err != nil {
+		return fmt.Errorf("cannot spool record: %w", err)
+	}
+	if _, err := d.spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("cannot read spool file: %w", err)
+	}
+
+	cr := csv.NewReader(bufio.NewReader(d.spool))
+	cr.FieldsPerRecord = -1
+	empty := NewRecord()
+	for {
+		line, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read spool file: %w", err)
+		}
+		spooled := make(map[string]int, len(line)/3)
+		keys := NewRecord()
+		for i := 0; i+2 < len(line); i += 3 {
+			spooled[line[i]] = i
+			keys.put(line[i], field{})
+		}
+		if err := w.checkKeys(keys); err != nil {
+			return err
+		}
+
+		record := make([]string, 0, len(w.header))
+		quote := make([]bool, 0, len(w.header))
+		for _, h := range w.header {
+			if i, ok := spooled[h]; ok {
+				record = append(record, line[i+1])
+				quote = append(quote, line[i+2] != "")
+				continue
+			}
+			value, q, err := w.formatField(empty, h)
+			if err != nil {
+				return err
+			}
+			record = append(record, value)
+			quote = append(quote, q)
+		}
+		if err := w.writeLine(record, quote); err != nil {
+			return fmt.Errorf("cannot write record: %s", err)
+		}
+	}
+}
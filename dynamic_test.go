@@ -0,0 +1,160 @@
+package csvhandler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDynamicWriter(t *testing.T) {
+	// Key and value pairs, in order
+	records := [][]interface{}{
+		{"first_name", "Holly", "last_name", "Franklin"},
+		{"first_name", "Giacobo", "age", 20},
+		{"first_name", "Aubrie", "city", "Paris", "age", 31},
+	}
+
+	testcases := map[string]struct {
+		opts     DynamicOptions
+		expected string
+	}{
+		"first record": {
+			opts:     DynamicOptions{Mode: HeaderFirstRecord},
+			expected: "first_name,last_name\nHolly,Franklin\nGiacobo,\nAubrie,\n",
+		},
+		"union": {
+			opts:     DynamicOptions{Mode: HeaderUnion},
+			expected: "first_name,last_name,age,city\nHolly,Franklin,,\nGiacobo,,20,\nAubrie,,31,Paris\n",
+		},
+		"union window": {
+			opts:     DynamicOptions{Mode: HeaderUnion, Window: 2},
+			expected: "first_name,last_name,age\nHolly,Franklin,\nGiacobo,,20\nAubrie,,31\n",
+		},
+		"sorted union": {
+			opts:     DynamicOptions{Mode: HeaderSortedUnion},
+			expected: "age,city,first_name,last_name\n,,Holly,Franklin\n20,,Giacobo,\n31,Paris,Aubrie,\n",
+		},
+		"spool": {
+			opts:     DynamicOptions{Mode: HeaderUnion, Window: 1, Spool: true},
+			expected: "first_name,last_name,age,city\nHolly,Franklin,,\nGiacobo,,20,\nAubrie,,31,Paris\n",
+		},
+		"spool first record": {
+			opts:     DynamicOptions{Mode: HeaderFirstRecord, Spool: true},
+			expected: "first_name,last_name\nHolly,Franklin\nGiacobo,\nAubrie,\n",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w := NewDynamicWriter(csv.NewWriter(&b), tc.opts)
+			for _, values := range records {
+				r := NewRecord()
+				for i := 0; i < len(values); i += 2 {
+					r.Set(values[i].(string), values[i+1])
+				}
+				require.NoError(t, w.Write(r))
+			}
+			require.NoError(t, w.WriteHeader())
+			require.NoError(t, w.Close())
+			assert.Equal(t, tc.expected, b.String())
+		})
+	}
+}
+
+func TestDynamicWriterFlush(t *testing.T) {
+	var b bytes.Buffer
+	w := NewDynamicWriter(csv.NewWriter(&b), DynamicOptions{Mode: HeaderUnion})
+	require.NoError(t, w.WriteHeader())
+	assert.Empty(t, b.String())
+
+	r := NewRecord()
+	r.Set("first_name", "Holly")
+	require.NoError(t, w.Write(r))
+	assert.Empty(t, b.String())
+	// Record is kept, not the given one
+	r.Set("first_name", "Giacobo")
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "first_name\nHolly\n", b.String())
+
+	r = NewRecord()
+	r.Set("first_name", "Aubrie")
+	r.Set("age", 31)
+	require.NoError(t, w.Write(r))
+	require.NoError(t, w.Close())
+	assert.Equal(t, "first_name\nHolly\nAubrie\n", b.String())
+}
+
+func TestDynamicWriterFlushFirst(t *testing.T) {
+	testcases := map[string]struct {
+		opts DynamicOptions
+	}{
+		"window": {
+			opts: DynamicOptions{Mode: HeaderUnion},
+		},
+		"spool": {
+			opts: DynamicOptions{Mode: HeaderUnion, Spool: true},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w := NewDynamicWriter(csv.NewWriter(&b), tc.opts)
+			require.NoError(t, w.Flush())
+			assert.Empty(t, b.String())
+
+			r := NewRecord()
+			r.Set("a", 1)
+			r.Set("b", 2)
+			require.NoError(t, w.Write(r))
+			require.NoError(t, w.Close())
+			assert.Equal(t, "a,b\n1,2\n", b.String())
+		})
+	}
+}
+
+func TestDynamicWriterSpool(t *testing.T) {
+	var b bytes.Buffer
+	w := NewDynamicWriter(csv.NewWriter(&b), DynamicOptions{Mode: HeaderSortedUnion, Spool: true})
+	w.SetDefault("country", "FR")
+	w.Formulas = FormulaEscape
+
+	r := NewRecord()
+	r.Set("name", "Holly")
+	r.Set("balance", 10.5, StringFormatter("%.2f"))
+	require.NoError(t, w.Write(r))
+	r = NewRecord()
+	r.Set("name", "=1+1")
+	r.Set("country", "US")
+	require.NoError(t, w.Write(r))
+	require.NoError(t, w.Flush())
+	assert.Empty(t, b.String())
+
+	require.NoError(t, w.Close())
+	assert.Equal(t, "balance,country,name\n10.50,FR,Holly\n,US,'=1+1\n", b.String())
+}
+
+func TestDynamicWriterEmpty(t *testing.T) {
+	var b bytes.Buffer
+	w := NewDynamicWriter(csv.NewWriter(&b), DynamicOptions{Spool: true})
+	require.NoError(t, w.Close())
+	assert.Empty(t, b.String())
+}
+
+func TestDynamicWriterError(t *testing.T) {
+	var b bytes.Buffer
+	w := NewDynamicWriter(csv.NewWriter(&b), DynamicOptions{Mode: HeaderUnion, Spool: true})
+	w.Formulas = FormulaReject
+	r := NewRecord()
+	r.Set("name", "=1+1")
+	err := w.Write(r)
+	require.Error(t, err)
+	assert.True(t, errors.As(err, &ErrFormulaInjection{}))
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,295 @@
+package csvhandler
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a character encoding used to read or write CSV files.
+//
+// Readers created with NewReaderFrom, Open or NewReaderAuto detect UTF-8 and UTF-16 byte order marks,
+// other encodings are set with the WithEncoding option.
+// To write a CSV file with an encoding, wrap the destination with NewEncoder before creating the `encoding/csv.Writer`.
+type Encoding struct {
+	name     string
+	bom      []byte
+	writeBOM bool
+	// decode appends to dst the UTF-8 encoding of the first character of src and returns the number of bytes read.
+	// It returns 0 if src is an incomplete sequence and -1 if src is an invalid sequence.
+	decode func(dst, src []byte, atEOF bool) ([]byte, int)
+	// encode appends to dst the encoding of r, false is returned if r cannot be encoded.
+	encode func(dst []byte, r rune) ([]byte, bool)
+}
+
+var (
+	// UTF8 is the UTF-8 encoding, invalid sequences are reported when decoding.
+	UTF8 = Encoding{
+		name:   "UTF-8",
+		bom:    []byte{0xef, 0xbb, 0xbf},
+		decode: decodeUTF8,
+		encode: encodeUTF8,
+	}
+	// UTF16LE is the UTF-16 little endian encoding.
+	UTF16LE = Encoding{
+		name:   "UTF-16LE",
+		bom:    []byte{0xff, 0xfe},
+		decode: decodeUTF16(false),
+		encode: encodeUTF16(false),
+	}
+	// UTF16BE is the UTF-16 big endian encoding.
+	UTF16BE = Encoding{
+		name:   "UTF-16BE",
+		bom:    []byte{0xfe, 0xff},
+		decode: decodeUTF16(true),
+		encode: encodeUTF16(true),
+	}
+	// Latin1 is the ISO-8859-1 encoding.
+	Latin1 = Encoding{
+		name:   "ISO-8859-1",
+		decode: decodeCharmap(nil),
+		encode: encodeCharmap(nil),
+	}
+	// Windows1252 is the Windows-1252 encoding, a superset of ISO-8859-1 used by Windows applications.
+	Windows1252 = Encoding{
+		name:   "Windows-1252",
+		decode: decodeCharmap(&windows1252),
+		encode: encodeCharmap(&windows1252),
+	}
+)
+
+// windows1252 holds the characters for bytes 0x80 to 0x9F, utf8.RuneError for undefined ones.
+var windows1252 = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
+
+// String returns the name of the encoding.
+func (e Encoding) String() string {
+	return e.name
+}
+
+// WithBOM returns a copy of this encoding whose encoders write the byte order mark first.
+// Only UTF-8 and UTF-16 have a byte order mark.
+func (e Encoding) WithBOM() Encoding {
+	e.writeBOM = true
+	return e
+}
+
+// NewDecoder returns a reader decoding r to UTF-8. A leading byte order mark of this encoding is skipped.
+// Invalid sequences are reported with an ErrInvalidEncoding error.
+func (e Encoding) NewDecoder(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if len(e.bom) > 0 {
+		if b, _ := br.Peek(len(e.bom)); bytes.Equal(b, e.bom) {
+			br.Discard(len(e.bom))
+			return &decoder{r: br, enc: e, offset: int64(len(e.bom))}
+		}
+	}
+	return &decoder{r: br, enc: e}
+}
+
+// NewEncoder returns a writer encoding the UTF-8 input to this encoding, see also WithBOM.
+// Characters that cannot be encoded are reported with an ErrInvalidEncoding error.
+func (e Encoding) NewEncoder(w io.Writer) io.Writer {
+	return &encoder{w: w, enc: e, bom: e.writeBOM}
+}
+
+// decodeBOM returns a reader decoding r according to its byte order mark, if any.
+// UTF-8 and UTF-16 byte order marks are detected, r is returned as is otherwise.
+func decodeBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	for _, e := range []Encoding{UTF8, UTF16LE, UTF16BE} {
+		if b, _ := br.Peek(len(e.bom)); bytes.Equal(b, e.bom) {
+			return e.NewDecoder(br)
+		}
+	}
+	return br
+}
+
+// decoder is the reader returned by Encoding.NewDecoder.
+type decoder struct {
+	r      io.Reader
+	enc    Encoding
+	in     []byte // Bytes read and not decoded yet
+	out    []byte // Bytes decoded and not returned yet
+	offset int64  // Offset of in[0] within the input
+	err    error
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		var buf [4096]byte
+		n, err := d.r.Read(buf[:])
+		d.in = append(d.in, buf[:n]...)
+		atEOF := err == io.EOF
+		if err != nil {
+			d.err = err
+		}
+
+		i := 0
+		for i < len(d.in) {
+			out, size := d.enc.decode(d.out, d.in[i:], atEOF)
+			if size == 0 {
+				break
+			}
+			if size < 0 {
+				d.err = ErrInvalidEncoding{encoding: d.enc.name, offset: d.offset + int64(i)}
+				break
+			}
+			d.out = out
+			i += size
+		}
+		d.in = d.in[:copy(d.in, d.in[i:])]
+		d.offset += int64(i)
+	}
+	n := copy(p, d.out)
+	d.out = d.out[:copy(d.out, d.out[n:])]
+	return n, nil
+}
+
+// encoder is the writer returned by Encoding.NewEncoder.
+type encoder struct {
+	w       io.Writer
+	enc     Encoding
+	bom     bool   // Whether the byte order mark must be written
+	pending []byte // Incomplete UTF-8 sequence from the previous write
+	offset  int64  // Offset of pending[0] within the input
+}
+
+func (e *encoder) Write(p []byte) (int, error) {
+	var out []byte
+	if e.bom {
+		out = append(out, e.enc.bom...)
+	}
+	in := append(e.pending, p...)
+	i := 0
+	for i < len(in) {
+		if !utf8.FullRune(in[i:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(in[i:])
+		encoded, ok := e.enc.encode(out, r)
+		if r == utf8.RuneError && size == 1 || !ok {
+			return 0, ErrInvalidEncoding{encoding: e.enc.name, offset: e.offset + int64(i)}
+		}
+		out = encoded
+		i += size
+	}
+	if _, err := e.w.Write(out); err != nil {
+		return 0, err
+	}
+	e.bom = false
+	e.pending = append([]byte(nil), in[i:]...)
+	e.offset += int64(i)
+	return len(p), nil
+}
+
+// appendRune appends the UTF-8 encoding of r to dst.
+func appendRune(dst []byte, r rune) []byte {
+	var b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(b[:], r)
+	return append(dst, b[:n]...)
+}
+
+func encodeUTF8(dst []byte, r rune) ([]byte, bool) {
+	return appendRune(dst, r), true
+}
+
+func decodeUTF8(dst, src []byte, atEOF bool) ([]byte, int) {
+	if !atEOF && !utf8.FullRune(src) {
+		return dst, 0
+	}
+	r, size := utf8.DecodeRune(src)
+	if r == utf8.RuneError && size <= 1 {
+		return dst, -1
+	}
+	return append(dst, src[:size]...), size
+}
+
+func decodeUTF16(bigEndian bool) func(dst, src []byte, atEOF bool) ([]byte, int) {
+	unit := func(b []byte) rune {
+		if bigEndian {
+			return rune(b[0])<<8 | rune(b[1])
+		}
+		return rune(b[1])<<8 | rune(b[0])
+	}
+	return func(dst, src []byte, atEOF bool) ([]byte, int) {
+		if len(src) < 2 {
+			if atEOF {
+				return dst, -1
+			}
+			return dst, 0
+		}
+		r := unit(src)
+		if !utf16.IsSurrogate(r) {
+			return appendRune(dst, r), 2
+		}
+		if len(src) < 4 {
+			if atEOF {
+				return dst, -1
+			}
+			return dst, 0
+		}
+		r = utf16.DecodeRune(r, unit(src[2:]))
+		if r == utf8.RuneError {
+			return dst, -1
+		}
+		return appendRune(dst, r), 4
+	}
+}
+
+func encodeUTF16(bigEndian bool) func(dst []byte, r rune) ([]byte, bool) {
+	unit := func(dst []byte, r rune) []byte {
+		if bigEndian {
+			return append(dst, byte(r>>8), byte(r))
+		}
+		return append(dst, byte(r), byte(r>>8))
+	}
+	return func(dst []byte, r rune) ([]byte, bool) {
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			return unit(unit(dst, r1), r2), true
+		}
+		return unit(dst, r), true
+	}
+}
+
+// decodeCharmap returns a decoder for a single byte encoding extending ISO-8859-1 with the given characters for bytes 0x80 to 0x9F.
+func decodeCharmap(high *[32]rune) func(dst, src []byte, atEOF bool) ([]byte, int) {
+	return func(dst, src []byte, atEOF bool) ([]byte, int) {
+		b := src[0]
+		if high != nil && b >= 0x80 && b < 0xa0 {
+			r := high[b-0x80]
+			if r == utf8.RuneError {
+				return dst, -1
+			}
+			return appendRune(dst, r), 1
+		}
+		return appendRune(dst, rune(b)), 1
+	}
+}
+
+// encodeCharmap returns an encoder for a single byte encoding extending ISO-8859-1 with the given characters for bytes 0x80 to 0x9F.
+func encodeCharmap(high *[32]rune) func(dst []byte, r rune) ([]byte, bool) {
+	return func(dst []byte, r rune) ([]byte, bool) {
+		if high != nil {
+			for i, h := range high {
+				if h == r && r != utf8.RuneError {
+					return append(dst, byte(0x80+i)), true
+				}
+			}
+			if r >= 0x80 && r < 0xa0 {
+				return dst, false
+			}
+		}
+		if r < 0x100 {
+			return append(dst, byte(r)), true
+		}
+		return dst, false
+	}
+}
@@ -0,0 +1,236 @@
+package csvhandler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	testcases := map[string]struct {
+		enc      Encoding
+		data     []byte
+		expected string
+		offset   int64
+		err      bool
+	}{
+		"utf8": {
+			enc:      UTF8,
+			data:     []byte("\xef\xbb\xbfcafé"),
+			expected: "café",
+		},
+		"utf8 invalid": {
+			enc:      UTF8,
+			data:     []byte("caf\xe9,x"),
+			expected: "caf",
+			offset:   3,
+			err:      true,
+		},
+		"utf8 truncated": {
+			enc:      UTF8,
+			data:     []byte("caf\xc3"),
+			expected: "caf",
+			offset:   3,
+			err:      true,
+		},
+		"utf16le": {
+			enc:      UTF16LE,
+			data:     []byte{0xff, 0xfe, 'c', 0, 'a', 0, 'f', 0, 0xe9, 0, 0x3d, 0xd8, 0x00, 0xde},
+			expected: "café😀",
+		},
+		"utf16be": {
+			enc:      UTF16BE,
+			data:     []byte{0, 'c', 0, 'a', 0, 'f', 0, 0xe9},
+			expected: "café",
+		},
+		"utf16 lone surrogate": {
+			enc:      UTF16BE,
+			data:     []byte{0, 'c', 0xdc, 0x00, 0, 'a'},
+			expected: "c",
+			offset:   2,
+			err:      true,
+		},
+		"utf16 odd length": {
+			enc:      UTF16LE,
+			data:     []byte{'c', 0, 'a'},
+			expected: "c",
+			offset:   2,
+			err:      true,
+		},
+		"latin1": {
+			enc:      Latin1,
+			data:     []byte("caf\xe9 \x80"),
+			expected: "café \u0080",
+		},
+		"windows1252": {
+			enc:      Windows1252,
+			data:     []byte("caf\xe9 \x80\x9c"),
+			expected: "café €œ",
+		},
+		"windows1252 undefined": {
+			enc:      Windows1252,
+			data:     []byte("ab\x81"),
+			expected: "ab",
+			offset:   2,
+			err:      true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			// One byte reader to check incomplete sequences are handled across reads
+			b, err := io.ReadAll(tc.enc.NewDecoder(iotest.OneByteReader(bytes.NewReader(tc.data))))
+			assert.Equal(t, tc.expected, string(b))
+			if tc.err {
+				var encErr ErrInvalidEncoding
+				require.True(t, errors.As(err, &encErr))
+				assert.Equal(t, tc.offset, encErr.Offset())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	testcases := map[string]struct {
+		enc      Encoding
+		value    string
+		expected []byte
+		offset   int64
+		err      bool
+	}{
+		"utf8 with bom": {
+			enc:      UTF8.WithBOM(),
+			value:    "café",
+			expected: []byte("\xef\xbb\xbfcafé"),
+		},
+		"utf16le with bom": {
+			enc:      UTF16LE.WithBOM(),
+			value:    "cé😀",
+			expected: []byte{0xff, 0xfe, 'c', 0, 0xe9, 0, 0x3d, 0xd8, 0x00, 0xde},
+		},
+		"utf16be": {
+			enc:      UTF16BE,
+			value:    "cé",
+			expected: []byte{0, 'c', 0, 0xe9},
+		},
+		"latin1": {
+			enc:      Latin1,
+			value:    "café",
+			expected: []byte("caf\xe9"),
+		},
+		"latin1 unsupported": {
+			enc:    Latin1,
+			value:  "a€",
+			offset: 1,
+			err:    true,
+		},
+		"windows1252": {
+			enc:      Windows1252,
+			value:    "café €",
+			expected: []byte("caf\xe9 \x80"),
+		},
+		"windows1252 unsupported": {
+			enc:    Windows1252,
+			value:  "ab\u0081",
+			offset: 2,
+			err:    true,
+		},
+		"invalid utf8": {
+			enc:    Latin1,
+			value:  "a\xff",
+			offset: 1,
+			err:    true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w := tc.enc.NewEncoder(&b)
+			var err error
+			// Write byte by byte to check incomplete sequences are handled across writes
+			for i := 0; i < len(tc.value) && err == nil; i++ {
+				_, err = w.Write([]byte{tc.value[i]})
+			}
+			if tc.err {
+				var encErr ErrInvalidEncoding
+				require.True(t, errors.As(err, &encErr))
+				assert.Equal(t, tc.offset, encErr.Offset())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, b.Bytes())
+			}
+		})
+	}
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	w := UTF8.NewEncoder(errWriter{})
+	_, err := w.Write([]byte("a"))
+	assert.Error(t, err)
+}
+
+func TestReaderEncoding(t *testing.T) {
+	testcases := map[string]struct {
+		data []byte
+		opts []ReaderOption
+	}{
+		"utf8 bom": {
+			data: []byte("\xef\xbb\xbfname,city\nHolly,Orléans\n"),
+		},
+		"utf16le bom": {
+			data: func() []byte {
+				var b bytes.Buffer
+				UTF16LE.WithBOM().NewEncoder(&b).Write([]byte("name\tcity\r\nHolly\tOrléans\r\n"))
+				return b.Bytes()
+			}(),
+		},
+		"windows1252": {
+			data: []byte("name;city\nHolly;Orl\xe9ans\n"),
+			opts: []ReaderOption{WithEncoding(Windows1252)},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReaderAuto(bytes.NewReader(tc.data), tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"name", "city"}, reader.header)
+			record, err := reader.Read()
+			require.NoError(t, err)
+			city, err := record.Get("city")
+			require.NoError(t, err)
+			assert.Equal(t, "Orléans", city)
+		})
+	}
+}
+
+func TestReaderFromEncoding(t *testing.T) {
+	data := []byte("\xff\xfen\x00a\x00m\x00e\x00\n\x00H\x00o\x00l\x00l\x00y\x00")
+	reader, err := NewReaderFrom(bytes.NewReader(tstGzip(t, data)))
+	require.NoError(t, err)
+	defer reader.Close()
+	assert.Equal(t, []string{"name"}, reader.header)
+	record, err := reader.Read()
+	require.NoError(t, err)
+	name, err := record.Get("name")
+	require.NoError(t, err)
+	assert.Equal(t, "Holly", name)
+}
+
+func TestReaderInvalidEncoding(t *testing.T) {
+	reader, err := NewReaderFrom(strings.NewReader("name\nHolly\nOrl\xe9ans\n"), WithEncoding(UTF8))
+	require.NoError(t, err)
+	_, err = reader.ReadAll()
+	var encErr ErrInvalidEncoding
+	require.True(t, errors.As(err, &encErr))
+	assert.Equal(t, int64(14), encErr.Offset())
+}
@@ -1,6 +1,9 @@
 package csvhandler
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ErrDuplicateKey means a duplicate key is detected within header
 type ErrDuplicateKey struct {
@@ -13,13 +16,73 @@ func (e ErrDuplicateKey) Error() string {
 
 // ErrUnknownKey means a requested key does not exist within header
 type ErrUnknownKey struct {
-	key string
+	key  string
+	keys []string // All the unknown keys, if several
 }
 
 func (e ErrUnknownKey) Error() string {
+	if len(e.keys) > 1 {
+		return fmt.Sprintf("keys '%s' do not exist", strings.Join(e.keys, "', '"))
+	}
 	return fmt.Sprintf("key '%s' does not exist", e.key)
 }
 
+// Keys returns all the unknown keys.
+func (e ErrUnknownKey) Keys() []string {
+	if len(e.keys) == 0 {
+		return []string{e.key}
+	}
+	return e.keys
+}
+
+// ErrFormulaInjection means a value to be written would be interpreted as a formula by spreadsheet tools
+type ErrFormulaInjection struct {
+	key string
+}
+
+func (e ErrFormulaInjection) Error() string {
+	return fmt.Sprintf("field with key '%s' starts with a formula character", e.key)
+}
+
+// ErrHeaderMismatch means a header does not match the expected one
+type ErrHeaderMismatch struct {
+	expected []string
+	actual   []string
+}
+
+func (e ErrHeaderMismatch) Error() string {
+	return fmt.Sprintf("header '%s' does not match expected header '%s'", strings.Join(e.actual, ","), strings.Join(e.expected, ","))
+}
+
+// ErrInvalidEncoding means an invalid byte sequence is read, or a character cannot be encoded, with an Encoding
+type ErrInvalidEncoding struct {
+	encoding string
+	offset   int64
+}
+
+func (e ErrInvalidEncoding) Error() string {
+	return fmt.Sprintf("invalid %s sequence at offset %d", e.encoding, e.offset)
+}
+
+// Offset returns the position in bytes of the invalid sequence within the input.
+func (e ErrInvalidEncoding) Offset() int64 {
+	return e.offset
+}
+
+// ErrMissingColumns means required columns are missing from header
+type ErrMissingColumns struct {
+	keys []string
+}
+
+func (e ErrMissingColumns) Error() string {
+	return fmt.Sprintf("missing columns '%s'", strings.Join(e.keys, "', '"))
+}
+
+// Keys returns the missing columns.
+func (e ErrMissingColumns) Keys() []string {
+	return e.keys
+}
+
 // ErrWrongType means the field with the requested key is not the expected type
 type ErrWrongType struct {
 	key string
@@ -29,3 +92,59 @@ type ErrWrongType struct {
 func (e ErrWrongType) Error() string {
 	return fmt.Sprintf("field with key '%s' is not the expected type, %v", e.key, e.err)
 }
+
+// ErrTooManyErrors means the number of rejected records exceeded Reader.MaxErrors
+type ErrTooManyErrors struct {
+	max int
+	err error
+}
+
+func (e ErrTooManyErrors) Error() string {
+	return fmt.Sprintf("too many errors (more than %d), last error: %v", e.max, e.err)
+}
+
+// Unwrap returns the last error encountered.
+func (e ErrTooManyErrors) Unwrap() error {
+	return e.err
+}
+
+// ErrCanceled means reading or writing stopped because the context is done, it wraps the context error
+type ErrCanceled struct {
+	row int
+	err error
+}
+
+func (e ErrCanceled) Error() string {
+	return fmt.Sprintf("stopped after %d records: %v", e.row, e.err)
+}
+
+// Row returns the number of records read or written before the context was done.
+func (e ErrCanceled) Row() int {
+	return e.row
+}
+
+// Unwrap returns the context error.
+func (e ErrCanceled) Unwrap() error {
+	return e.err
+}
+
+// ParseError is returned by the Record getters for records read by a Reader.
+// It wraps ErrUnknownKey or ErrWrongType with the position of the field in the input.
+type ParseError struct {
+	Line   int    // Line where the record starts, starting at 1
+	Column int    // Column of the field within the header, starting at 1 as in `encoding/csv.ParseError` (0 if the key is not in the header)
+	Key    string // Column name
+	Err    error  // The actual error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("record on line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("record on line %d, column %d (%s): %v", e.Line, e.Column, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
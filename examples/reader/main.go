@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/csv"
 	"fmt"
-	"io"
 	"log"
 	"os"
 
@@ -20,29 +19,25 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	for {
-		// Read handler to get a record
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Fatal(err)
-		}
-
+	// Each calls the given function for each record until the end of the input
+	err = reader.Each(func(record *csvhandler.Record) error {
 		// Read first_name, last_name and age column from record
 		firstName, err := record.Get("first_name")
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 		lastName, err := record.Get("last_name")
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 		age, err := record.GetInt("age")
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 		fmt.Printf("%s %s is %d\n", firstName, lastName, age)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,166 @@
+package csvhandler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExcelTimeLayout is the default layout of time values written by an Excel Writer, recognized by Excel as a date.
+const ExcelTimeLayout = "2006-01-02 15:04:05"
+
+// ExcelOptions configures the Writer created by NewExcelWriter.
+type ExcelOptions struct {
+	Comma      rune   // Field delimiter, ',' if not set
+	SepLine    bool   // Whether a `sep=` line is written first, so Excel uses Comma whatever its regional settings, see NewExcelWriter
+	TimeLayout string // Layout of time.Time values without formatter, ExcelTimeLayout if empty
+}
+
+// NewExcelWriter creates a new Writer to w, with the given header, producing files Excel opens cleanly:
+//   - content is UTF-8 with a byte order mark and lines end with CRLF,
+//   - an optional `sep=` line sets the field delimiter,
+//   - formatted values Excel would alter when guessing their type are written as text formulas, for instance `="00123"`:
+//     numbers with a leading zero (zip codes, IDs...) or a leading plus sign (phone numbers), and numbers with
+//     more than 15 digits (card or account numbers), Excel precision,
+//   - time.Time values without formatter are written with an Excel recognizable layout.
+//
+// Quoting is not enough as Excel ignores quotes when guessing types. Other tools read the `="..."` form as is.
+// Excel is known to ignore the byte order mark when a `sep=` line is present, so non ASCII characters
+// may be garbled with SepLine: prefer the delimiter of the target regional settings instead.
+//
+// Other values are formatted as with NewWriter, defaults and formatters can be set the same way.
+// If a duplicate is detected among column names, ErrDuplicateKey is returned.
+func NewExcelWriter(w io.Writer, opts ExcelOptions, header ...string) (*Writer, error) {
+	comma := opts.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	if !validDelim(comma) {
+		return nil, fmt.Errorf("invalid field delimiter %q", comma)
+	}
+	layout := opts.TimeLayout
+	if layout == "" {
+		layout = ExcelTimeLayout
+	}
+
+	lw := &quotingWriter{
+		w:        bufio.NewWriter(w),
+		comma:    comma,
+		preamble: string(UTF8.bom),
+	}
+	if opts.SepLine {
+		lw.preamble += "sep=" + string(comma) + "\r\n"
+	}
+	writer, err := newWriter(lw, header)
+	if err != nil {
+		return nil, err
+	}
+	timeFormatter := TimeFormatter(layout)
+	writer.formatter = func(value interface{}) (string, error) {
+		switch value.(type) {
+		case time.Time, *time.Time:
+			return timeFormatter(value)
+		}
+		return defaultFormatter(value)
+	}
+	writer.quote = excelText
+	return writer, nil
+}
+
+// excelMaxDigits is the number of significant digits Excel keeps in numbers.
+const excelMaxDigits = 15
+
+// excelText returns whether the given formatted value is a number Excel would alter:
+// with a leading zero or plus sign, or with more than excelMaxDigits digits.
+func excelText(formatted string) bool {
+	v := strings.TrimPrefix(strings.TrimPrefix(formatted, "-"), "+")
+	digits, point := 0, false
+	for i, c := range v {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !point && i > 0:
+			point = true
+		default:
+			return false
+		}
+	}
+	if digits == 0 || strings.HasSuffix(v, ".") {
+		return false
+	}
+	leadingZero := len(v) > 1 && v[0] == '0' && v[1] != '.'
+	return strings.HasPrefix(formatted, "+") || leadingZero || digits > excelMaxDigits
+}
+
+// quotingWriter is a lineWriter writing CRLF terminated lines, fields are quoted when needed,
+// fields forced as text are written as `="..."` formulas.
+type quotingWriter struct {
+	w        *bufio.Writer
+	comma    rune
+	preamble string // Written before the first line
+	err      error
+}
+
+func (q *quotingWriter) Write(fields []string, quote []bool) error {
+	if q.err != nil {
+		return q.err
+	}
+	if q.preamble != "" {
+		if _, q.err = q.w.WriteString(q.preamble); q.err != nil {
+			return q.err
+		}
+		q.preamble = ""
+	}
+	for i, f := range fields {
+		if i > 0 {
+			q.w.WriteRune(q.comma)
+		}
+		if i < len(quote) && quote[i] {
+			f = `="` + strings.ReplaceAll(f, `"`, `""`) + `"`
+			if !strings.ContainsRune(f, q.comma) {
+				q.w.WriteString(f)
+				continue
+			}
+		}
+		if !q.needsQuotes(f) {
+			q.w.WriteString(f)
+			continue
+		}
+		q.w.WriteByte('"')
+		q.w.WriteString(strings.ReplaceAll(f, `"`, `""`))
+		q.w.WriteByte('"')
+	}
+	_, q.err = q.w.WriteString("\r\n")
+	return q.err
+}
+
+func (q *quotingWriter) Flush() {
+	if q.err == nil {
+		q.err = q.w.Flush()
+	}
+}
+
+func (q *quotingWriter) Error() error {
+	return q.err
+}
+
+// needsQuotes returns whether the field must be quoted to be read back, same as `encoding/csv.Writer`.
+func (q *quotingWriter) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsRune(field, q.comma) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
+}
+
+// validDelim returns whether r can be used as field delimiter, same as `encoding/csv`.
+func validDelim(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
@@ -0,0 +1,140 @@
+package csvhandler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExcelWriter(t *testing.T) {
+	registered := time.Date(2021, 1, 26, 10, 20, 8, 0, time.UTC)
+
+	testcases := map[string]struct {
+		opts       ExcelOptions
+		values     map[string]interface{}
+		formatters map[string]Formatter
+		expected   string
+		err        bool
+	}{
+		"regular": {
+			values: map[string]interface{}{
+				"id":         "00123",
+				"name":       "Holly",
+				"age":        27,
+				"registered": registered,
+			},
+			expected: "\ufeffid,name,age,registered\r\n=\"00123\",Holly,27,2021-01-26 10:20:08\r\n",
+		},
+		"sep line": {
+			opts: ExcelOptions{Comma: ';', SepLine: true},
+			values: map[string]interface{}{
+				"id":         "+33612345678",
+				"name":       "Franklin; Holly",
+				"age":        27.5,
+				"registered": &registered,
+			},
+			expected: "\ufeffsep=;\r\nid;name;age;registered\r\n=\"+33612345678\";\"Franklin; Holly\";27.5;2021-01-26 10:20:08\r\n",
+		},
+		"time layout": {
+			opts: ExcelOptions{TimeLayout: "02/01/2006"},
+			values: map[string]interface{}{
+				"name":       " Holly",
+				"registered": registered,
+			},
+			expected: "\ufeffid,name,age,registered\r\n,\" Holly\",,26/01/2021\r\n",
+		},
+		"formatter": {
+			values: map[string]interface{}{
+				"id":  12,
+				"age": 27,
+			},
+			formatters: map[string]Formatter{
+				"id": StringFormatter("%05s"),
+			},
+			expected: "\ufeffid,name,age,registered\r\n=\"00012\",,27,\r\n",
+		},
+		"numeric strings": {
+			values: map[string]interface{}{
+				"id":   "4111111111111111",
+				"name": "0.5",
+				"age":  "27",
+			},
+			expected: "\ufeffid,name,age,registered\r\n=\"4111111111111111\",0.5,27,\r\n",
+		},
+		"delimiter in text": {
+			opts: ExcelOptions{Comma: '.'},
+			values: map[string]interface{}{
+				"id": "00.5",
+			},
+			expected: "\ufeffid.name.age.registered\r\n\"=\"\"00.5\"\"\"...\r\n",
+		},
+		"invalid delimiter": {
+			opts: ExcelOptions{Comma: '"'},
+			err:  true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewExcelWriter(&b, tc.opts, "id", "name", "age", "registered")
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for k, f := range tc.formatters {
+				w.SetFormatter(k, f)
+			}
+			record := NewRecord()
+			for k, v := range tc.values {
+				record.Set(k, v)
+			}
+
+			require.NoError(t, w.WriteHeader())
+			require.NoError(t, w.Write(record))
+			assert.Equal(t, tc.expected, b.String())
+		})
+	}
+}
+
+func TestExcelText(t *testing.T) {
+	testcases := map[string]bool{
+		"00123":               true,
+		"+33612345678":        true,
+		"-0012":               true,
+		"00.5":                true,
+		"1234567890123456":    true,
+		"123456789012345":     false,
+		"0":                   false,
+		"0.5":                 false,
+		"-12.5":               false,
+		"27":                  false,
+		"1e5":                 false,
+		"Holly":               false,
+		"":                    false,
+		"012a":                false,
+		"1234567890123456.5.": false,
+	}
+
+	for v, expected := range testcases {
+		t.Run(v, func(t *testing.T) {
+			assert.Equal(t, expected, excelText(v))
+		})
+	}
+}
+
+func TestNewExcelWriterErrors(t *testing.T) {
+	_, err := NewExcelWriter(nil, ExcelOptions{}, "id", "id")
+	assert.True(t, errors.As(err, &ErrDuplicateKey{}))
+
+	w, err := NewExcelWriter(tstWriter{err: fmt.Errorf("write error")}, ExcelOptions{}, "id")
+	require.NoError(t, err)
+	assert.Error(t, w.WriteHeader())
+	assert.Error(t, w.Write(NewRecord()))
+}
@@ -56,3 +56,16 @@ func chainFormatter(formatters ...Formatter) Formatter {
 		return "", nil // Function should return with the condition within the for loop
 	}
 }
+
+// NumberFormatter returns a new formatter that writes numbers in the given format with the given number of decimals.
+// If precision is negative, the smallest number of decimals necessary to represent the value is used.
+// Integers, floats, *big.Int, *big.Float, *big.Rat and strings holding a decimal number are allowed as value for the returned formatter.
+func NumberFormatter(format NumberFormat, precision int) Formatter {
+	return func(value interface{}) (string, error) {
+		s, err := decimalString(value, precision)
+		if err != nil {
+			return "", err
+		}
+		return format.format(s), nil
+	}
+}
@@ -1,6 +1,8 @@
 package csvhandler
 
 import (
+	"math"
+	"math/big"
 	"testing"
 	"time"
 
@@ -130,3 +132,108 @@ func TestChainFormatter(t *testing.T) {
 		})
 	}
 }
+
+func TestNumberFormatter(t *testing.T) {
+	german := NumberFormat{Decimal: ',', Grouping: '.', Currency: "€", CurrencyAfter: true}
+	testcases := map[string]struct {
+		format    NumberFormat
+		precision int
+		value     interface{}
+		expected  string
+		err       bool
+	}{
+		"float": {
+			format:    NumberFormat{Grouping: ','},
+			precision: 2,
+			value:     1234567.891,
+			expected:  "1,234,567.89",
+		},
+		"shortest float": {
+			format:    NumberFormat{Grouping: ','},
+			precision: -1,
+			value:     float32(1234.5),
+			expected:  "1,234.5",
+		},
+		"negative float32": {
+			format:    NumberFormat{Parentheses: true},
+			precision: -1,
+			value:     float32(-0.1),
+			expected:  "(0.1)",
+		},
+		"int": {
+			format:    german,
+			precision: 2,
+			value:     -1234,
+			expected:  "-1.234,00 €",
+		},
+		"small int": {
+			format:    german,
+			precision: 0,
+			value:     uint8(12),
+			expected:  "12 €",
+		},
+		"accounting": {
+			format:    NumberFormat{Grouping: ',', Currency: "$", Parentheses: true},
+			precision: 2,
+			value:     -1234.5,
+			expected:  "($1,234.50)",
+		},
+		"big int": {
+			format:    NumberFormat{Grouping: ' '},
+			precision: -1,
+			value:     new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil),
+			expected:  "1 000 000 000 000 000 000 000",
+		},
+		"big float": {
+			format:    german,
+			precision: 3,
+			value:     big.NewFloat(1234.5),
+			expected:  "1.234,500 €",
+		},
+		"big rat": {
+			format:    german,
+			precision: -1,
+			value:     big.NewRat(123456789012345678, 1000),
+			expected:  "123.456.789.012.345,678 €",
+		},
+		"string": {
+			format:    german,
+			precision: 2,
+			value:     "1234.567",
+			expected:  "1.234,57 €",
+		},
+		"shortest string": {
+			format:    german,
+			precision: -1,
+			value:     "-1234.5",
+			expected:  "-1.234,5 €",
+		},
+		"not a number": {
+			format: german,
+			value:  "abc",
+			err:    true,
+		},
+		"infinity": {
+			format: german,
+			value:  math.Inf(1),
+			err:    true,
+		},
+		"wrong type": {
+			format: german,
+			value:  true,
+			err:    true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			res, err := NumberFormatter(tc.format, tc.precision)(tc.value)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package csvhandler
+
+import "strings"
+
+// formulaChars are the characters that make spreadsheet tools interpret a value as a formula when leading.
+const formulaChars = "=+-@\t\r"
+
+// FormulaPolicy defines how a Writer handles values that spreadsheet tools would interpret as formulas,
+// that is values starting with '=', '+', '-', '@', a tab or a carriage return.
+type FormulaPolicy int
+
+const (
+	// FormulaAllow writes values as is.
+	FormulaAllow FormulaPolicy = iota
+	// FormulaEscape prefixes values with a single quote so they are displayed as text.
+	FormulaEscape
+	// FormulaReject makes Write return ErrFormulaInjection.
+	FormulaReject
+)
+
+// SetFormulaExempt exempts the given columns from the Formulas policy, for instance numeric columns with negative values.
+func (w *Writer) SetFormulaExempt(key ...string) {
+	for _, k := range key {
+		w.exempt[k] = struct{}{}
+	}
+}
+
+// checkFormula applies the Formulas policy to the formatted value of the given column.
+func (w *Writer) checkFormula(column, value string) (string, error) {
+	if w.Formulas == FormulaAllow || value == "" || !strings.ContainsRune(formulaChars, rune(value[0])) {
+		return value, nil
+	}
+	if _, exempt := w.exempt[column]; exempt {
+		return value, nil
+	}
+	if w.Formulas == FormulaReject {
+		return "", ErrFormulaInjection{key: column}
+	}
+	return "'" + value, nil
+}
@@ -0,0 +1,108 @@
+package csvhandler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormulaPolicy(t *testing.T) {
+	testcases := map[string]struct {
+		policy   FormulaPolicy
+		values   map[string]interface{}
+		expected string
+		err      bool
+	}{
+		"allow": {
+			policy: FormulaAllow,
+			values: map[string]interface{}{
+				"name":   "=HYPERLINK(\"http://evil\")",
+				"amount": -12.5,
+			},
+			expected: "\"=HYPERLINK(\"\"http://evil\"\")\",-12.5\n",
+		},
+		"escape": {
+			policy: FormulaEscape,
+			values: map[string]interface{}{
+				"name":   "@SUM(A1)",
+				"amount": -12.5,
+			},
+			expected: "'@SUM(A1),-12.5\n",
+		},
+		"escape all characters": {
+			policy: FormulaEscape,
+			values: map[string]interface{}{
+				"name":   "+1",
+				"amount": "\t-1",
+			},
+			expected: "'+1,\"\t-1\"\n",
+		},
+		"escape control characters": {
+			policy: FormulaEscape,
+			values: map[string]interface{}{
+				"name": "\r=1",
+			},
+			expected: "\"'\r=1\",\n",
+		},
+		"regular values": {
+			policy: FormulaReject,
+			values: map[string]interface{}{
+				"name":   "Holly",
+				"amount": 12,
+			},
+			expected: "Holly,12\n",
+		},
+		"reject": {
+			policy: FormulaReject,
+			values: map[string]interface{}{
+				"name": "-2+3",
+			},
+			err: true,
+		},
+		"reject exempt": {
+			policy: FormulaReject,
+			values: map[string]interface{}{
+				"amount": "-12.5",
+			},
+			expected: ",-12.5\n",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(csv.NewWriter(&b), "name", "amount")
+			require.NoError(t, err)
+			w.Formulas = tc.policy
+			w.SetFormulaExempt("amount")
+			record := NewRecord()
+			for k, v := range tc.values {
+				record.Set(k, v)
+			}
+
+			err = w.Write(record)
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &ErrFormulaInjection{}))
+				assert.Empty(t, b.String())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestFormulaPolicyDefault(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(csv.NewWriter(&b), "name")
+	require.NoError(t, err)
+	w.Formulas = FormulaEscape
+	w.SetDefault("name", "=1")
+	require.NoError(t, w.Write(NewRecord()))
+	assert.Equal(t, "'=1\n", b.String())
+}
@@ -0,0 +1,252 @@
+package csvhandler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ReaderOption configures how a Reader processes the header, see NewReaderWithOptions.
+type ReaderOption func(*Reader)
+
+// WithHeader sets the column names instead of reading them from the first record.
+func WithHeader(header ...string) ReaderOption {
+	return func(r *Reader) {
+		r.header = header
+	}
+}
+
+// WithCSVReader sets a function to configure the underlying `encoding/csv.Reader`, for instance its field delimiter.
+// It is mostly useful with the constructors creating the `encoding/csv.Reader`, such as NewReaderFrom or Open.
+func WithCSVReader(configure func(*csv.Reader)) ReaderOption {
+	return func(r *Reader) {
+		r.configure = append(r.configure, configure)
+	}
+}
+
+// WithEncoding sets the character encoding of the input read by NewReaderFrom, Open or NewReaderAuto.
+// Without this option, UTF-8 and UTF-16 are detected with their byte order mark, UTF-8 is assumed otherwise.
+func WithEncoding(enc Encoding) ReaderOption {
+	return func(r *Reader) {
+		r.encoding = &enc
+	}
+}
+
+// WithNullTokens sets the values read as null, for instance "NULL", "NA" or `\N`, see Record.IsNull.
+// The empty string is not a null token unless given.
+func WithNullTokens(tokens ...string) ReaderOption {
+	return func(r *Reader) {
+		if r.layout.nulls == nil {
+			r.layout.nulls = make(map[string]struct{})
+		}
+		for _, t := range tokens {
+			r.layout.nulls[t] = struct{}{}
+		}
+	}
+}
+
+// WithNormalizer normalizes the column names of the header with the given normalizers.
+// Keys given to the Record functions are normalized the same way, so `record.Get("First Name")`
+// and `record.Get("first_name")` return the same field with NormalizeCase and NormalizeSpaces.
+//
+// If multiple normalizers are provided they are applied in the given order.
+func WithNormalizer(normalizers ...Normalizer) ReaderOption {
+	return func(r *Reader) {
+		r.layout.normalize = chainNormalizer(append([]Normalizer{r.layout.normalize}, normalizers...)...)
+	}
+}
+
+// WithAliases sets alternative column names for canonical keys.
+// Aliases is a map with canonical keys as keys and their alternative names as values, for instance
+// `map[string][]string{"email": {"e-mail", "mail", "Email Address"}}`.
+//
+// If the canonical key is not in the header, the first alternative name found is renamed to the canonical key.
+// The record fields are then accessible with both names.
+func WithAliases(aliases map[string][]string) ReaderOption {
+	return func(r *Reader) {
+		if r.aliases == nil {
+			r.aliases = make(map[string][]string)
+		}
+		for k, v := range aliases {
+			r.aliases[k] = append(r.aliases[k], v...)
+		}
+	}
+}
+
+// WithRequired sets the columns that must be in the header, after aliases are resolved.
+// If some are missing, NewReaderWithOptions returns ErrMissingColumns listing all of them.
+func WithRequired(columns ...string) ReaderOption {
+	return func(r *Reader) {
+		r.required = append(r.required, columns...)
+	}
+}
+
+// DuplicatePolicy defines how a Reader handles duplicate column names.
+type DuplicatePolicy int
+
+const (
+	// DuplicateError returns ErrDuplicateKey when a duplicate is detected, this is the default policy.
+	DuplicateError DuplicatePolicy = iota
+	// DuplicateRename renames the duplicates with a numbered suffix: `amount`, `amount_2`, `amount_3`...
+	DuplicateRename
+	// DuplicateKeepFirst keeps the value of the first column with the duplicate name.
+	DuplicateKeepFirst
+	// DuplicateKeepLast keeps the value of the last column with the duplicate name.
+	DuplicateKeepLast
+	// DuplicateMulti keeps all the values, Get returns the first one and GetAll returns all of them.
+	DuplicateMulti
+)
+
+// WithDuplicates sets how duplicate column names, after normalization, are handled.
+// Default is DuplicateError.
+func WithDuplicates(policy DuplicatePolicy) ReaderOption {
+	return func(r *Reader) {
+		r.duplicates = policy
+	}
+}
+
+// Normalizer is the function that returns a normalized version of a column name.
+type Normalizer func(string) string
+
+// NormalizeTrimSpace removes leading and trailing white spaces.
+func NormalizeTrimSpace(key string) string {
+	return strings.TrimSpace(key)
+}
+
+// NormalizeBOM removes the leading byte order mark (U+FEFF) glued to the first column name by some editors.
+func NormalizeBOM(key string) string {
+	return strings.TrimPrefix(key, "\ufeff")
+}
+
+// NormalizeCase folds the column name to lower case.
+func NormalizeCase(key string) string {
+	return strings.ToLower(key)
+}
+
+// NormalizeSpaces replaces each sequence of white spaces with an underscore.
+func NormalizeSpaces(key string) string {
+	return strings.Join(strings.FieldsFunc(key, unicode.IsSpace), "_")
+}
+
+// chainNormalizer returns a Normalizer applying the given ones in order, nil normalizers are skipped.
+func chainNormalizer(normalizers ...Normalizer) Normalizer {
+	return func(key string) string {
+		for _, n := range normalizers {
+			if n != nil {
+				key = n(key)
+			}
+		}
+		return key
+	}
+}
+
+// indexHeader builds the column indexes of the given normalized header, handling duplicates according to the Reader policy.
+// With DuplicateRename, the header is updated with the new names.
+func (r *Reader) indexHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, h := range header {
+		if _, duplicate := columns[h]; duplicate {
+			switch r.duplicates {
+			case DuplicateRename:
+				for n := 2; ; n++ {
+					renamed := fmt.Sprintf("%s_%d", h, n)
+					if !contains(header, renamed) {
+						header[i] = renamed
+						columns[renamed] = i
+						break
+					}
+				}
+			case DuplicateKeepFirst, DuplicateMulti:
+			case DuplicateKeepLast:
+				columns[h] = i
+			default:
+				return nil, ErrDuplicateKey{key: h}
+			}
+			continue
+		}
+		columns[h] = i
+	}
+	return columns, nil
+}
+
+// contains returns whether the given slice contains the given value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveAliases renames the header columns matching an alias to their canonical key.
+// Header must be already normalized.
+func (r *Reader) resolveAliases() {
+	canonicals := make([]string, 0, len(r.aliases))
+	for c := range r.aliases {
+		canonicals = append(canonicals, c)
+	}
+	// Sort for a deterministic resolution if aliases overlap
+	sort.Strings(canonicals)
+
+	for _, c := range canonicals {
+		canonical := r.layout.key(c)
+		if _, ok := r.layout.columns[canonical]; ok {
+			continue
+		}
+		for _, a := range r.aliases[c] {
+			alias := r.layout.key(a)
+			i, ok := r.layout.columns[alias]
+			if !ok {
+				continue
+			}
+			r.header[i] = canonical
+			delete(r.layout.columns, alias)
+			r.layout.columns[canonical] = i
+			if r.layout.aliases == nil {
+				r.layout.aliases = make(map[string]string)
+			}
+			r.layout.aliases[alias] = canonical
+			break
+		}
+	}
+}
+
+// checkRequired returns ErrMissingColumns if some required columns are not in the header.
+func (r *Reader) checkRequired() error {
+	var missing []string
+	for _, c := range r.required {
+		if _, ok := r.layout.columns[r.layout.key(c)]; !ok {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		return ErrMissingColumns{keys: missing}
+	}
+	return nil
+}
+
+// decode returns a reader decoding src with the encoding set by WithEncoding, or detected with the byte order mark.
+func (r *Reader) decode(src io.Reader) io.Reader {
+	if r.encoding != nil {
+		return r.encoding.NewDecoder(src)
+	}
+	return decodeBOM(src)
+}
+
+// key returns the given key normalized with the Reader normalizers and resolved with the aliases, if any.
+func (l *layout) key(k string) string {
+	if l == nil {
+		return k
+	}
+	if l.normalize != nil {
+		k = l.normalize(k)
+	}
+	if canonical, ok := l.aliases[k]; ok {
+		return canonical
+	}
+	return k
+}
@@ -0,0 +1,296 @@
+package csvhandler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizers(t *testing.T) {
+	testcases := map[string]struct {
+		normalizer Normalizer
+		value      string
+		expected   string
+	}{
+		"trim space": {
+			normalizer: NormalizeTrimSpace,
+			value:      " first name\t",
+			expected:   "first name",
+		},
+		"bom": {
+			normalizer: NormalizeBOM,
+			value:      "\ufefffirst_name",
+			expected:   "first_name",
+		},
+		"case": {
+			normalizer: NormalizeCase,
+			value:      "FIRST_Name",
+			expected:   "first_name",
+		},
+		"spaces": {
+			normalizer: NormalizeSpaces,
+			value:      " first  name ",
+			expected:   "first_name",
+		},
+		"chain": {
+			normalizer: chainNormalizer(NormalizeBOM, nil, NormalizeCase, NormalizeSpaces),
+			value:      "\ufeffFirst Name ",
+			expected:   "first_name",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.normalizer(tc.value))
+		})
+	}
+}
+
+func TestNewReaderWithOptions(t *testing.T) {
+	testcases := map[string]struct {
+		data    string
+		opts    []ReaderOption
+		header  []string
+		get     map[string]string
+		err     bool
+		errType interface{}
+	}{
+		"no option": {
+			data:   "First Name,age\nHolly,27",
+			header: []string{"First Name", "age"},
+			get:    map[string]string{"First Name": "Holly"},
+		},
+		"with header": {
+			data:   "Holly,27",
+			opts:   []ReaderOption{WithHeader("first_name", "age")},
+			header: []string{"first_name", "age"},
+			get:    map[string]string{"first_name": "Holly"},
+		},
+		"normalized": {
+			data: "\ufeffFirst Name, AGE \nHolly,27",
+			opts: []ReaderOption{
+				WithNormalizer(NormalizeBOM, NormalizeTrimSpace),
+				WithNormalizer(NormalizeCase, NormalizeSpaces),
+			},
+			header: []string{"first_name", "age"},
+			get: map[string]string{
+				"first_name": "Holly",
+				"FIRST NAME": "Holly",
+				" Age":       "27",
+			},
+		},
+		"duplicate after normalization": {
+			data:    "first_name,FIRST_NAME\nHolly,Holly",
+			opts:    []ReaderOption{WithNormalizer(NormalizeCase)},
+			err:     true,
+			errType: &ErrDuplicateKey{},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReaderWithOptions(csv.NewReader(strings.NewReader(tc.data)), tc.opts...)
+			if tc.err {
+				require.Error(t, err)
+				if tc.errType != nil {
+					assert.True(t, errors.As(err, tc.errType))
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.header, reader.header)
+
+			record, err := reader.Read()
+			require.NoError(t, err)
+			for k, v := range tc.get {
+				val, err := record.Get(k)
+				require.NoError(t, err)
+				assert.Equal(t, v, val)
+			}
+		})
+	}
+}
+
+func TestNormalizedRecord(t *testing.T) {
+	reader, err := NewReaderWithOptions(csv.NewReader(strings.NewReader("First Name,Age\nHolly,abc")),
+		WithNormalizer(NormalizeCase, NormalizeSpaces))
+	require.NoError(t, err)
+	record, err := reader.Read()
+	require.NoError(t, err)
+
+	// Error position is resolved with the normalized key
+	_, err = record.GetInt("AGE")
+	var perr *ParseError
+	require.True(t, errors.As(err, &perr))
+	assert.Equal(t, 2, perr.Column)
+
+	// Set uses the normalized key
+	record.Set("Last Name", "Franklin")
+	v, err := record.Get("last_name")
+	require.NoError(t, err)
+	assert.Equal(t, "Franklin", v)
+
+	// Writer columns are resolved with the normalized key
+	var b bytes.Buffer
+	w, err := NewWriter(csv.NewWriter(&b), "First Name", "Last Name")
+	require.NoError(t, err)
+	require.NoError(t, w.Write(record))
+	assert.Equal(t, "Holly,Franklin\n", b.String())
+}
+
+func TestAliases(t *testing.T) {
+	aliases := map[string][]string{
+		"email": {"e-mail", "mail", "Email Address"},
+		"name":  {"full_name"},
+	}
+
+	testcases := map[string]struct {
+		data   string
+		opts   []ReaderOption
+		header []string
+		get    map[string]string
+		err    bool
+		errMsg string
+	}{
+		"first alias": {
+			data:   "full_name,mail\nHolly,holly@example.com",
+			opts:   []ReaderOption{WithAliases(aliases)},
+			header: []string{"name", "email"},
+			get: map[string]string{
+				"email":     "holly@example.com",
+				"mail":      "holly@example.com",
+				"name":      "Holly",
+				"full_name": "Holly",
+			},
+		},
+		"canonical present": {
+			data:   "email,mail\nholly@example.com,other@example.com",
+			opts:   []ReaderOption{WithAliases(aliases)},
+			header: []string{"email", "mail"},
+			get: map[string]string{
+				"email": "holly@example.com",
+				"mail":  "other@example.com",
+			},
+		},
+		"normalized alias": {
+			data:   "EMAIL_ADDRESS\nholly@example.com",
+			opts:   []ReaderOption{WithNormalizer(NormalizeCase, NormalizeSpaces), WithAliases(aliases)},
+			header: []string{"email"},
+			get: map[string]string{
+				"Email":         "holly@example.com",
+				"Email Address": "holly@example.com",
+			},
+		},
+		"required": {
+			data:   "e-mail,full_name\nholly@example.com,Holly",
+			opts:   []ReaderOption{WithAliases(aliases), WithRequired("email", "name")},
+			header: []string{"email", "name"},
+		},
+		"missing required": {
+			data:   "id,mail\n1,holly@example.com",
+			opts:   []ReaderOption{WithAliases(aliases), WithRequired("email", "name", "age")},
+			err:    true,
+			errMsg: "missing columns 'name', 'age'",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReaderWithOptions(csv.NewReader(strings.NewReader(tc.data)), tc.opts...)
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &ErrMissingColumns{}))
+				assert.Equal(t, tc.errMsg, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.header, reader.header)
+
+			record, err := reader.Read()
+			require.NoError(t, err)
+			for k, v := range tc.get {
+				val, err := record.Get(k)
+				require.NoError(t, err)
+				assert.Equal(t, v, val)
+			}
+		})
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	data := "id,amount,amount,amount_2\n1,10,20,30"
+
+	testcases := map[string]struct {
+		policy DuplicatePolicy
+		header []string
+		get    map[string][]string
+		err    bool
+	}{
+		"error": {
+			policy: DuplicateError,
+			err:    true,
+		},
+		"rename": {
+			policy: DuplicateRename,
+			header: []string{"id", "amount", "amount_3", "amount_2"},
+			get: map[string][]string{
+				"amount":   {"10"},
+				"amount_3": {"20"},
+				"amount_2": {"30"},
+			},
+		},
+		"keep first": {
+			policy: DuplicateKeepFirst,
+			header: []string{"id", "amount", "amount", "amount_2"},
+			get: map[string][]string{
+				"amount": {"10"},
+			},
+		},
+		"keep last": {
+			policy: DuplicateKeepLast,
+			header: []string{"id", "amount", "amount", "amount_2"},
+			get: map[string][]string{
+				"amount": {"20"},
+			},
+		},
+		"multi": {
+			policy: DuplicateMulti,
+			header: []string{"id", "amount", "amount", "amount_2"},
+			get: map[string][]string{
+				"amount":   {"10", "20"},
+				"amount_2": {"30"},
+			},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReaderWithOptions(csv.NewReader(strings.NewReader(data)), WithDuplicates(tc.policy))
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &ErrDuplicateKey{}))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.header, reader.header)
+
+			record, err := reader.Read()
+			require.NoError(t, err)
+			for k, v := range tc.get {
+				values, err := record.GetAll(k)
+				require.NoError(t, err)
+				assert.Equal(t, v, values)
+				first, err := record.Get(k)
+				require.NoError(t, err)
+				assert.Equal(t, v[0], first)
+			}
+			_, err = record.GetAll("unknown")
+			assert.True(t, errors.As(err, &ErrUnknownKey{}))
+		})
+	}
+}
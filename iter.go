@@ -0,0 +1,34 @@
+//go:build go1.23
+
+package csvhandler
+
+import (
+	"io"
+	"iter"
+)
+
+// All returns an iterator over the remaining records, to be used with a `for range` loop:
+//
+//	for record, err := range reader.All() {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Iteration stops at the end of the input, or after yielding the first error.
+// If MaxErrors is set, records with a parse error are rejected instead, see Rejected.
+// Use Each before Go 1.23.
+func (r *Reader) All() iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		for {
+			record, _, err := r.next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(record, err) || err != nil {
+				return
+			}
+		}
+	}
+}
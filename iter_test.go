@@ -0,0 +1,79 @@
+//go:build go1.23
+
+package csvhandler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderAll(t *testing.T) {
+	testcases := map[string]struct {
+		data      string
+		maxErrors int
+		stop      int // Number of records after which the loop breaks, 0 to never break
+		expected  []string
+		errs      int
+	}{
+		"regular": {
+			data:     "name\nHolly\nGiacobo\nAubrie",
+			expected: []string{"Holly", "Giacobo", "Aubrie"},
+		},
+		"early break": {
+			data:     "name\nHolly\nGiacobo\nAubrie",
+			stop:     2,
+			expected: []string{"Holly", "Giacobo"},
+		},
+		"empty": {
+			data: "name",
+		},
+		"error": {
+			data:     "name\nHolly\n\"Giacobo\nAubrie",
+			expected: []string{"Holly"},
+			errs:     1,
+		},
+		"rejected": {
+			data:      "name\nHolly\nGia\"cobo\nAubrie",
+			maxErrors: 1,
+			expected:  []string{"Holly", "Aubrie"},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReader(csv.NewReader(bytes.NewBufferString(tc.data)))
+			require.NoError(t, err)
+			reader.MaxErrors = tc.maxErrors
+
+			var names []string
+			errs := 0
+			for record, err := range reader.All() {
+				if err != nil {
+					errs++
+					continue
+				}
+				name, err := record.Get("name")
+				require.NoError(t, err)
+				names = append(names, name)
+				if len(names) == tc.stop {
+					break
+				}
+			}
+			assert.Equal(t, tc.expected, names)
+			assert.Equal(t, tc.errs, errs)
+		})
+	}
+}
+
+func TestTypedReaderAllRange(t *testing.T) {
+	var names []string
+	for p, err := range newTstTypedReader[tstName](t, "regular.csv").All() {
+		require.NoError(t, err)
+		names = append(names, p.FirstName)
+	}
+	assert.Equal(t, []string{"Holly", "Giacobo", "Aubrie", "Kristoforo", "Jasmine"}, names)
+}
@@ -0,0 +1,111 @@
+package csvhandler
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+)
+
+// IsNull returns whether the field of the given key is null, that is a nil value
+// or, for records read by a Reader, a value among the null tokens (see WithNullTokens).
+// If the key is missing, ErrUnknownKey is returned.
+func (r *Record) IsNull(key string) (bool, error) {
+	f, ok := r.field(key)
+	if !ok {
+		return false, r.wrapErr(key, ErrUnknownKey{key: key})
+	}
+	return r.isNull(f), nil
+}
+
+// isNull returns whether the given field is null.
+func (r *Record) isNull(f field) bool {
+	if isNil(f.value) {
+		return true
+	}
+	if s, ok := f.value.(string); ok && r.layout != nil {
+		_, null := r.layout.nulls[s]
+		return null
+	}
+	return false
+}
+
+// isNil returns whether v is nil or a nil pointer.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// GetNullString returns the field corresponding to the given key, not valid if null (see IsNull).
+// If the key is missing, ErrUnknownKey is returned.
+func (r *Record) GetNullString(key string) (sql.NullString, error) {
+	if null, err := r.IsNull(key); err != nil || null {
+		return sql.NullString{}, err
+	}
+	v, err := r.Get(key)
+	return sql.NullString{String: v, Valid: err == nil}, err
+}
+
+// GetNullBool returns as a boolean the field corresponding to the given key, not valid if null (see IsNull).
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetNullBool(key string) (sql.NullBool, error) {
+	if null, err := r.IsNull(key); err != nil || null {
+		return sql.NullBool{}, err
+	}
+	v, err := r.GetBool(key)
+	return sql.NullBool{Bool: v, Valid: err == nil}, err
+}
+
+// GetNullInt64 returns as an int64 the field corresponding to the given key, not valid if null (see IsNull).
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetNullInt64(key string) (sql.NullInt64, error) {
+	if null, err := r.IsNull(key); err != nil || null {
+		return sql.NullInt64{}, err
+	}
+	v, err := r.GetInt64(key)
+	return sql.NullInt64{Int64: v, Valid: err == nil}, err
+}
+
+// GetNullFloat64 returns as a float64 the field corresponding to the given key, not valid if null (see IsNull).
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetNullFloat64(key string) (sql.NullFloat64, error) {
+	if null, err := r.IsNull(key); err != nil || null {
+		return sql.NullFloat64{}, err
+	}
+	v, err := r.GetFloat64(key)
+	return sql.NullFloat64{Float64: v, Valid: err == nil}, err
+}
+
+// GetNullTime returns as a time the field corresponding to the given key using the given layout,
+// not valid if null (see IsNull).
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetNullTime(layout, key string) (sql.NullTime, error) {
+	if null, err := r.IsNull(key); err != nil || null {
+		return sql.NullTime{}, err
+	}
+	v, err := r.GetTime(layout, key)
+	return sql.NullTime{Time: v, Valid: err == nil}, err
+}
+
+// nullValue returns the value to be written for v, nil if v is null:
+// nil, a nil pointer or a driver.Valuer (such as sql.NullInt64) whose value is nil.
+// Other valuers are written as their driver.Value, []byte values as strings.
+func nullValue(v interface{}) (interface{}, error) {
+	if isNil(v) {
+		return nil, nil
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if b, ok := value.([]byte); ok {
+			return string(b), err
+		}
+		return value, err
+	}
+	return v, nil
+}
@@ -0,0 +1,225 @@
+package csvhandler
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNull(t *testing.T) {
+	data := "name,age,score,city\nHolly,NULL,\\N,\n"
+	reader, err := NewReaderWithOptions(csv.NewReader(bytes.NewBufferString(data)), WithNullTokens("NULL", `\N`))
+	require.NoError(t, err)
+	record, err := reader.Read()
+	require.NoError(t, err)
+
+	for key, expected := range map[string]bool{
+		"name":  false,
+		"age":   true,
+		"score": true,
+		"city":  false,
+	} {
+		null, err := record.IsNull(key)
+		require.NoError(t, err)
+		assert.Equal(t, expected, null, key)
+	}
+	_, err = record.IsNull("country")
+	assert.True(t, errors.As(err, &ErrUnknownKey{}))
+
+	var age *int
+	r := NewRecord()
+	r.Set("name", nil)
+	r.Set("age", age)
+	r.Set("city", "NULL")
+	for key, expected := range map[string]bool{
+		"name": true,
+		"age":  true,
+		"city": false,
+	} {
+		null, err := r.IsNull(key)
+		require.NoError(t, err)
+		assert.Equal(t, expected, null, key)
+	}
+}
+
+func TestGetNull(t *testing.T) {
+	data := "name,age,balance,active,registered\nHolly,27,100.5,true,2018-11-05T12:55:10Z\nNA,NA,NA,NA,NA\nHolly,abc,abc,abc,abc\n"
+	reader, err := NewReaderWithOptions(csv.NewReader(bytes.NewBufferString(data)), WithNullTokens("NA"))
+	require.NoError(t, err)
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+
+	// Valid values
+	r := records[0]
+	name, err := r.GetNullString("name")
+	require.NoError(t, err)
+	assert.Equal(t, sql.NullString{String: "Holly", Valid: true}, name)
+	age, err := r.GetNullInt64("age")
+	require.NoError(t, err)
+	assert.Equal(t, sql.NullInt64{Int64: 27, Valid: true}, age)
+	balance, err := r.GetNullFloat64("balance")
+	require.NoError(t, err)
+	assert.Equal(t, sql.NullFloat64{Float64: 100.5, Valid: true}, balance)
+	active, err := r.GetNullBool("active")
+	require.NoError(t, err)
+	assert.Equal(t, sql.NullBool{Bool: true, Valid: true}, active)
+	registered, err := r.GetNullTime(time.RFC3339, "registered")
+	require.NoError(t, err)
+	assert.Equal(t, sql.NullTime{Time: time.Date(2018, 11, 5, 12, 55, 10, 0, time.UTC), Valid: true}, registered)
+
+	// Null values
+	r = records[1]
+	name, err = r.GetNullString("name")
+	require.NoError(t, err)
+	assert.False(t, name.Valid)
+	age, err = r.GetNullInt64("age")
+	require.NoError(t, err)
+	assert.False(t, age.Valid)
+	balance, err = r.GetNullFloat64("balance")
+	require.NoError(t, err)
+	assert.False(t, balance.Valid)
+	active, err = r.GetNullBool("active")
+	require.NoError(t, err)
+	assert.False(t, active.Valid)
+	registered, err = r.GetNullTime(time.RFC3339, "registered")
+	require.NoError(t, err)
+	assert.False(t, registered.Valid)
+
+	// Invalid values
+	r = records[2]
+	_, err = r.GetNullInt64("age")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+	_, err = r.GetNullFloat64("balance")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+	_, err = r.GetNullBool("active")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+	_, err = r.GetNullTime(time.RFC3339, "registered")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+	_, err = r.GetNullString("country")
+	assert.True(t, errors.As(err, &ErrUnknownKey{}))
+}
+
+type tstValuer struct{}
+
+func (tstValuer) Value() (driver.Value, error) {
+	return nil, fmt.Errorf("valuer error")
+}
+
+type tstBytesValuer string
+
+func (v tstBytesValuer) Value() (driver.Value, error) {
+	return []byte(v), nil
+}
+
+func (v tstBytesValuer) String() string {
+	return string(v)
+}
+
+func TestWriteNull(t *testing.T) {
+	var age *int
+	testcases := map[string]struct {
+		value    interface{}
+		expected string
+		err      bool
+	}{
+		"nil": {
+			value:    nil,
+			expected: "NULL,\n",
+		},
+		"nil pointer": {
+			value:    age,
+			expected: "NULL,\n",
+		},
+		"null valuer": {
+			value:    sql.NullInt64{},
+			expected: "NULL,\n",
+		},
+		"valid valuer": {
+			value:    sql.NullInt64{Int64: 0, Valid: true},
+			expected: "0,\n",
+		},
+		"bytes valuer": {
+			value:    tstBytesValuer("abc"),
+			expected: "abc,\n",
+		},
+		"valuer error": {
+			value: tstValuer{},
+			err:   true,
+		},
+		"zero": {
+			value:    0,
+			expected: "0,\n",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(csv.NewWriter(&b), "age", "name")
+			require.NoError(t, err)
+			w.NullValue = "NULL"
+			r := NewRecord()
+			r.Set("age", tc.value)
+
+			err = w.Write(r)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestReadIntoNull(t *testing.T) {
+	data := "name,age,balance,registered\nHolly,NULL,NULL,NULL\nGiacobo,20,10.5,2018-11-05\n"
+	reader, err := NewReaderWithOptions(csv.NewReader(bytes.NewBufferString(data)), WithNullTokens("NULL"))
+	require.NoError(t, err)
+
+	type person struct {
+		Name       sql.NullString  `csv:"name"`
+		Age        *int            `csv:"age"`
+		Balance    sql.NullFloat64 `csv:"balance"`
+		Registered sql.NullTime    `csv:"registered,format=2006-01-02"`
+	}
+	var people []person
+	require.NoError(t, reader.ReadAllInto(&people))
+	require.Len(t, people, 2)
+
+	assert.Equal(t, sql.NullString{String: "Holly", Valid: true}, people[0].Name)
+	assert.Nil(t, people[0].Age)
+	assert.False(t, people[0].Balance.Valid)
+	assert.False(t, people[0].Registered.Valid)
+
+	require.NotNil(t, people[1].Age)
+	assert.Equal(t, 20, *people[1].Age)
+	assert.Equal(t, sql.NullFloat64{Float64: 10.5, Valid: true}, people[1].Balance)
+	assert.Equal(t, sql.NullTime{Time: time.Date(2018, 11, 5, 0, 0, 0, 0, time.UTC), Valid: true}, people[1].Registered)
+}
+
+func TestWriteStructNull(t *testing.T) {
+	type account struct {
+		Name    string        `csv:"name"`
+		Age     *int          `csv:"age"`
+		Balance sql.NullInt64 `csv:"balance"`
+	}
+	age := 27
+
+	var b bytes.Buffer
+	w, err := NewWriterFor(csv.NewWriter(&b), account{})
+	require.NoError(t, err)
+	w.NullValue = "NULL"
+	require.NoError(t, w.WriteStruct(account{Name: "Holly", Age: &age, Balance: sql.NullInt64{Int64: 5, Valid: true}}))
+	require.NoError(t, w.WriteStruct(account{Name: "Giacobo"}))
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "Holly,27,5\nGiacobo,NULL,NULL\n", b.String())
+}
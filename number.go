@@ -0,0 +1,242 @@
+package csvhandler
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat describes how numbers are written, for instance `1.234,56` or `(1 234,56 €)`.
+// It is used to read numbers with GetFloat64With and GetIntWith, and to write them with NumberFormatter.
+// When reading, the currency symbol is optional and grouping separators must be followed by groups of 3 digits.
+type NumberFormat struct {
+	Decimal       rune   // Decimal separator, '.' if not set
+	Grouping      rune   // Digit grouping separator, no grouping if not set
+	Currency      string // Currency symbol, optional when reading
+	CurrencyAfter bool   // Whether the currency symbol follows the number, separated with a space
+	Parentheses   bool   // Whether negative numbers are enclosed in parentheses (accounting style)
+}
+
+// decimal returns the decimal separator of this format.
+func (nf NumberFormat) decimal() rune {
+	if nf.Decimal == 0 {
+		return '.'
+	}
+	return nf.Decimal
+}
+
+// isGrouping returns whether c is the grouping separator of this format.
+// A space grouping separator also matches the no-break spaces used by some locales.
+func (nf NumberFormat) isGrouping(c rune) bool {
+	if nf.Grouping == 0 {
+		return false
+	}
+	if nf.Grouping == ' ' {
+		return c == ' ' || c == '\u00a0' || c == '\u202f'
+	}
+	return c == nf.Grouping
+}
+
+// normalize returns the given number in the format accepted by strconv, for instance `-1234.56` for `(1.234,56 €)`.
+func (nf NumberFormat) normalize(s string) (string, error) {
+	v := strings.TrimSpace(s)
+	negative := false
+	if nf.Parentheses && strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		negative = true
+		v = strings.TrimSpace(v[1 : len(v)-1])
+	}
+	if !negative && strings.HasPrefix(v, "-") {
+		negative = true
+		v = strings.TrimSpace(v[1:])
+	}
+	if nf.Currency != "" {
+		if strings.HasPrefix(v, nf.Currency) {
+			v = strings.TrimSpace(strings.TrimPrefix(v, nf.Currency))
+		} else if strings.HasSuffix(v, nf.Currency) {
+			v = strings.TrimSpace(strings.TrimSuffix(v, nf.Currency))
+		}
+		if !negative && strings.HasPrefix(v, "-") {
+			negative = true
+			v = v[1:]
+		}
+	}
+
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	// group is the number of digits since the last grouping separator, -1 if none,
+	// separators must be followed by groups of 3 digits
+	digits, group, fraction := 0, -1, false
+	for _, c := range v {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+			digits++
+			if group >= 0 && !fraction {
+				group++
+			}
+		case c == nf.decimal() && !fraction && (group == -1 || group == 3):
+			b.WriteByte('.')
+			fraction = true
+		case nf.isGrouping(c) && !fraction && digits > 0 && (group == -1 || group == 3):
+			group = 0
+		default:
+			return "", fmt.Errorf("'%s' is not a number", s)
+		}
+	}
+	if digits == 0 || !fraction && group != -1 && group != 3 {
+		return "", fmt.Errorf("'%s' is not a number", s)
+	}
+	return b.String(), nil
+}
+
+// format returns the given decimal number, such as `-1234.56`, written in this format.
+func (nf NumberFormat) format(number string) string {
+	negative := strings.HasPrefix(number, "-")
+	number = strings.TrimPrefix(number, "-")
+	integer, fraction := number, ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		integer, fraction = number[:i], number[i+1:]
+	}
+
+	var b strings.Builder
+	if !nf.CurrencyAfter {
+		b.WriteString(nf.Currency)
+	}
+	for i, c := range integer {
+		if i > 0 && nf.Grouping != 0 && (len(integer)-i)%3 == 0 {
+			b.WriteRune(nf.Grouping)
+		}
+		b.WriteRune(c)
+	}
+	if fraction != "" {
+		b.WriteRune(nf.decimal())
+		b.WriteString(fraction)
+	}
+	if nf.CurrencyAfter && nf.Currency != "" {
+		b.WriteString(" " + nf.Currency)
+	}
+
+	switch {
+	case !negative:
+		return b.String()
+	case nf.Parentheses:
+		return "(" + b.String() + ")"
+	default:
+		return "-" + b.String()
+	}
+}
+
+// decimalString returns the given number as a decimal string with the given number of decimals.
+// If precision is negative, the smallest number of decimals necessary to represent the value is used.
+// Strings are read as decimal numbers, so that NumberFormatter can also be used as a column formatter.
+func decimalString(value interface{}, precision int) (string, error) {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprintf("%d", v)
+		if precision > 0 {
+			s += "." + strings.Repeat("0", precision)
+		}
+		return s, nil
+	case float32:
+		return formatFloat(float64(v), precision, 32)
+	case float64:
+		return formatFloat(v, precision, 64)
+	case *big.Int:
+		return decimalString(new(big.Rat).SetInt(v), precision)
+	case *big.Float:
+		if v.IsInf() {
+			return "", fmt.Errorf("%v is not a finite number", v)
+		}
+		return v.Text('f', precision), nil
+	case *big.Rat:
+		if precision < 0 {
+			decimals, ok := exactDecimals(v)
+			if !ok {
+				f, _ := v.Float64()
+				return decimalString(f, precision)
+			}
+			precision = decimals
+		}
+		return v.FloatString(precision), nil
+	case string:
+		q, ok := new(big.Rat).SetString(strings.TrimSpace(v))
+		if !ok || strings.ContainsRune(v, '/') {
+			return "", fmt.Errorf("'%s' is not a number", v)
+		}
+		return decimalString(q, precision)
+	default:
+		return "", fmt.Errorf("%v (%T) is not a number", value, value)
+	}
+}
+
+// formatFloat returns the given float of the given bit size as a decimal string, see decimalString.
+func formatFloat(f float64, precision, bitSize int) (string, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("%v is not a finite number", f)
+	}
+	return strconv.FormatFloat(f, 'f', precision, bitSize), nil
+}
+
+// exactDecimals returns the number of decimals of the given number, false if its decimal expansion is infinite.
+func exactDecimals(q *big.Rat) (int, bool) {
+	d := new(big.Int).Set(q.Denom())
+	two, five := 0, 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		two++
+	}
+	q5, m := new(big.Int), new(big.Int)
+	for {
+		if q5.QuoRem(d, big.NewInt(5), m); m.Sign() != 0 {
+			break
+		}
+		d.Set(q5)
+		five++
+	}
+	if five > two {
+		two = five
+	}
+	return two, d.Cmp(big.NewInt(1)) == 0
+}
+
+// GetFloat64With returns as a float the field corresponding to the given key, written in the given format.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetFloat64With(format NumberFormat, key string) (float64, error) {
+	v, err := r.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := format.normalize(v)
+	if err != nil {
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
+	}
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
+	}
+	return f, nil
+}
+
+// GetIntWith returns as an integer the field corresponding to the given key, written in the given format.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type or has decimals, ErrWrongType is returned.
+func (r *Record) GetIntWith(format NumberFormat, key string) (int, error) {
+	v, err := r.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := format.normalize(v)
+	if err != nil {
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
+	}
+	i, err := strconv.ParseInt(n, 10, strconv.IntSize)
+	if err != nil {
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
+	}
+	return int(i), nil
+}
@@ -0,0 +1,178 @@
+package csvhandler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	tstEnglish = NumberFormat{Decimal: '.', Grouping: ',', Currency: "$", Parentheses: true}
+	tstGerman  = NumberFormat{Decimal: ',', Grouping: '.', Currency: "€", CurrencyAfter: true}
+	tstFrench  = NumberFormat{Decimal: ',', Grouping: ' ', Currency: "€", CurrencyAfter: true}
+)
+
+func TestGetFloat64With(t *testing.T) {
+	testcases := map[string]struct {
+		format   NumberFormat
+		value    string
+		expected float64
+		err      bool
+	}{
+		"plain": {
+			format:   NumberFormat{},
+			value:    "1234.56",
+			expected: 1234.56,
+		},
+		"grouping": {
+			format:   tstEnglish,
+			value:    "1,234,567.8",
+			expected: 1234567.8,
+		},
+		"currency before": {
+			format:   tstEnglish,
+			value:    "$1,234.56",
+			expected: 1234.56,
+		},
+		"parentheses": {
+			format:   tstEnglish,
+			value:    "($1,234.56)",
+			expected: -1234.56,
+		},
+		"minus before currency": {
+			format:   tstEnglish,
+			value:    "-$1,234.56",
+			expected: -1234.56,
+		},
+		"minus after currency": {
+			format:   tstEnglish,
+			value:    "$-1,234.56",
+			expected: -1234.56,
+		},
+		"decimal comma": {
+			format:   tstGerman,
+			value:    "1.234,56",
+			expected: 1234.56,
+		},
+		"currency after": {
+			format:   tstGerman,
+			value:    "-1.234,56 €",
+			expected: -1234.56,
+		},
+		"space grouping": {
+			format:   tstFrench,
+			value:    "1 234,56",
+			expected: 1234.56,
+		},
+		"no-break space grouping": {
+			format:   tstFrench,
+			value:    "1\u00a0234\u202f567,5 €",
+			expected: 1234567.5,
+		},
+		"integer": {
+			format:   tstFrench,
+			value:    "12",
+			expected: 12,
+		},
+		"wrong decimal separator": {
+			format: tstGerman,
+			value:  "1234.56",
+			err:    true,
+		},
+		"wrong grouping": {
+			format: tstEnglish,
+			value:  "12,34,567",
+			err:    true,
+		},
+		"grouping after decimal": {
+			format: tstEnglish,
+			value:  "1.234,5",
+			err:    true,
+		},
+		"parentheses not allowed": {
+			format: tstGerman,
+			value:  "(1,5)",
+			err:    true,
+		},
+		"no digits": {
+			format: tstEnglish,
+			value:  "$",
+			err:    true,
+		},
+		"text": {
+			format: tstEnglish,
+			value:  "abc",
+			err:    true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			record := NewRecord()
+			record.Set("balance", tc.value)
+
+			val, err := record.GetFloat64With(tc.format, "balance")
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &ErrWrongType{}))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, val)
+			}
+		})
+	}
+
+	_, err := NewRecord().GetFloat64With(tstEnglish, "balance")
+	assert.True(t, errors.As(err, &ErrUnknownKey{}))
+}
+
+func TestGetIntWith(t *testing.T) {
+	testcases := map[string]struct {
+		format   NumberFormat
+		value    string
+		expected int
+		err      bool
+	}{
+		"grouping": {
+			format:   tstGerman,
+			value:    "1.234.567",
+			expected: 1234567,
+		},
+		"parentheses": {
+			format:   tstEnglish,
+			value:    "(1,234)",
+			expected: -1234,
+		},
+		"decimals": {
+			format: tstEnglish,
+			value:  "1,234.5",
+			err:    true,
+		},
+		"text": {
+			format: tstEnglish,
+			value:  "abc",
+			err:    true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			record := NewRecord()
+			record.Set("count", tc.value)
+
+			val, err := record.GetIntWith(tc.format, "count")
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &ErrWrongType{}))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, val)
+			}
+		})
+	}
+
+	_, err := NewRecord().GetIntWith(tstEnglish, "count")
+	assert.True(t, errors.As(err, &ErrUnknownKey{}))
+}
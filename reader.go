@@ -2,10 +2,34 @@ package csvhandler
 
 import (
 	"encoding/csv"
+	"errors"
+	"fmt"
 	"io"
+	"reflect"
 	"sync"
 )
 
+// ExtraFieldPrefix is the prefix of the keys given to extra fields when FieldCountKeepExtra is used.
+// Extra fields are named `_extra_1`, `_extra_2`...
+const ExtraFieldPrefix = "_extra_"
+
+// FieldCountPolicy defines how a Reader handles records with a number of fields different from the header.
+// Policies can be combined, for instance `FieldCountPad | FieldCountTruncate`.
+type FieldCountPolicy int
+
+// FieldCountStrict returns a csv.ErrFieldCount error when a record does not have the same number of fields as the header.
+const FieldCountStrict FieldCountPolicy = 0
+
+const (
+	// FieldCountPad sets an empty value to missing trailing fields.
+	FieldCountPad FieldCountPolicy = 1 << iota
+	// FieldCountTruncate ignores extra fields.
+	FieldCountTruncate
+	// FieldCountKeepExtra keeps extra fields with the keys `_extra_1`, `_extra_2`... (see ExtraFieldPrefix).
+	// It takes precedence over FieldCountTruncate.
+	FieldCountKeepExtra
+)
+
 // Reader reads records from a CSV-encoded file.
 //
 // It internally wraps a `encoding/csv.Reader` and uses it to read the data.
@@ -14,7 +38,45 @@ import (
 type Reader struct {
 	reader *csv.Reader
 	header []string
+	layout *layout
+	count  int
 	mutex  *sync.Mutex
+	// FieldCount defines how records with a number of fields different from the header are handled.
+	// Default is FieldCountStrict.
+	FieldCount FieldCountPolicy
+	// MaxErrors is the number of records ReadAll, ReadAllInto and Each can reject before aborting with ErrTooManyErrors.
+	// Default is 0, the first error aborts the reading. A negative value means no limit.
+	// Rejected records are reported by the Rejected function.
+	MaxErrors int
+	// Rejects, if set, receives the raw fields of the rejected records when they are available.
+	// See also RejectTo.
+	Rejects    *csv.Writer
+	rejected   []RejectedRecord
+	aliases    map[string][]string // Alternative column names by canonical key, see WithAliases
+	required   []string            // Required columns, see WithRequired
+	duplicates DuplicatePolicy     // See WithDuplicates
+	configure  []func(*csv.Reader) // See WithCSVReader
+	encoding   *Encoding           // See WithEncoding
+	headerRow  bool                // Whether the header has been read from the input
+	rawHeader  []string            // Header as read from the input, before normalization
+	closers    []io.Closer         // Resources to release on Close
+	// nextMember returns a reader on the next member of the input, io.EOF if none, see NewReaderFrom
+	nextMember func() (*csv.Reader, string, error)
+}
+
+// RejectedRecord describes a record rejected by ReadAll, ReadAllInto or Each (see Reader.MaxErrors).
+type RejectedRecord struct {
+	Line   int      // Line where the record starts, starting at 1
+	Fields []string // Raw fields of the record, nil if they cannot be parsed
+	Err    error    // Cause of the rejection
+}
+
+// layout holds the column information shared by the records read by a Reader.
+type layout struct {
+	columns   map[string]int      // Column indexes by key
+	normalize Normalizer          // Column names normalizer, nil if none
+	aliases   map[string]string   // Canonical keys by alias
+	nulls     map[string]struct{} // Values read as null, see WithNullTokens
 }
 
 // NewReader creates a new Reader from the given `encoding/csv.Reader`.
@@ -24,66 +86,252 @@ type Reader struct {
 //
 // If a duplicate is detected among column names, ErrDuplicateKey is returned.
 func NewReader(r *csv.Reader, header ...string) (*Reader, error) {
-	if len(header) == 0 {
+	return NewReaderWithOptions(r, WithHeader(header...))
+}
+
+// NewReaderWithOptions creates a new Reader from the given `encoding/csv.Reader` and options.
+//
+// Unless the header is given with the WithHeader option, NewReaderWithOptions will read the first record and extract column names.
+// As it wraps `encoding/csv.Reader`, any errors returned by the `Read` function can be returned here (including io.EOF is the reader is empty).
+//
+// If a duplicate is detected among column names, after normalization, ErrDuplicateKey is returned.
+// If required columns are missing, after aliases are resolved, ErrMissingColumns is returned.
+func NewReaderWithOptions(r *csv.Reader, opts ...ReaderOption) (*Reader, error) {
+	return newReader(r, applyOptions(opts))
+}
+
+// applyOptions returns a new Reader configured with the given options, to be initialized with newReader.
+func applyOptions(opts []ReaderOption) *Reader {
+	reader := &Reader{
+		layout: &layout{},
+		mutex:  &sync.Mutex{},
+	}
+	for _, opt := range opts {
+		opt(reader)
+	}
+	return reader
+}
+
+// newReader initializes the given Reader, configured by applyOptions, to read from r.
+func newReader(r *csv.Reader, reader *Reader) (*Reader, error) {
+	reader.reader = r
+	reader.configureCSVReader(r)
+
+	if len(reader.header) == 0 {
 		// Read headers to save column keys
 		var err error
-		header, err = r.Read()
+		reader.header, err = r.Read()
 		if err != nil {
 			return nil, err
 		}
+		reader.headerRow = true
+		reader.rawHeader = append([]string(nil), reader.header...)
 	}
 
-	// Check for duplicates
-	set := make(map[string]struct{})
-	for _, h := range header {
-		if _, duplicate := set[h]; duplicate {
-			return nil, ErrDuplicateKey{key: h}
-		}
-		set[h] = struct{}{}
+	header := make([]string, len(reader.header))
+	for i, h := range reader.header {
+		header[i] = reader.layout.key(h)
 	}
 
-	return &Reader{
-		reader: r,
-		header: header,
-		mutex:  &sync.Mutex{},
-	}, nil
+	columns, err := reader.indexHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	reader.header = header
+	reader.layout.columns = columns
+	reader.resolveAliases()
+	if err := reader.checkRequired(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Header returns the column names of this Reader, as used for the keys of the records,
+// that is after normalization, aliases and duplicates handling.
+func (r *Reader) Header() []string {
+	header := make([]string, len(r.header))
+	copy(header, r.header)
+	return header
 }
 
 // Read reads one record (a slice of fields) from handler.
 //
-// If the record has an unexpected number of fields, Read returns the record along with the error csv.ErrFieldCount.
+// If the record has an unexpected number of fields, Read returns the error csv.ErrFieldCount unless FieldCount allows it.
 // If there is no data left to be read, Read returns nil, io.EOF.
+// Errors from the underlying `encoding/csv.Reader` are *csv.ParseError holding the line and column of the error.
+//
+// The returned Record keeps its position in the input (see Record.Line and Record.Index),
+// getters errors are then wrapped in a ParseError holding the line, column index and column name of the field.
 func (r *Reader) Read() (*Record, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	r.reader.FieldsPerRecord = len(r.header)
-	record, err := r.reader.Read()
+	record, _, err := r.read()
+	return record, err
+}
+
+// read reads one record and also returns its raw fields, even on a csv.ErrFieldCount error.
+// Caller must hold the mutex.
+func (r *Reader) read() (*Record, []string, error) {
+	record, err := r.readFields()
 	if err != nil {
-		return nil, err
+		return nil, record, err
 	}
+	line, _ := r.reader.FieldPos(0)
 
-	fields := make(map[string]field)
-	for i, v := range record {
-		// At this point, we are sure `record` and `r.header` have the same size
-		fields[r.header[i]] = field{
+	missing := len(record) < len(r.header) && r.FieldCount&FieldCountPad == 0
+	extra := len(record) > len(r.header) && r.FieldCount&(FieldCountTruncate|FieldCountKeepExtra) == 0
+	if missing || extra {
+		return nil, record, &csv.ParseError{StartLine: line, Line: line, Column: 1, Err: csv.ErrFieldCount}
+	}
+
+	rec := &Record{
+		fields: make(map[string]field, len(r.header)),
+		keys:   make([]string, 0, len(r.header)),
+		line:   line,
+		layout: r.layout,
+	}
+	for i, h := range r.header {
+		var v string
+		if i < len(record) {
+			v = record[i]
+		}
+		if f, duplicate := rec.fields[h]; duplicate {
+			switch r.duplicates {
+			case DuplicateKeepFirst:
+				continue
+			case DuplicateMulti:
+				if f.values == nil {
+					f.values = []string{f.value.(string)}
+				}
+				f.values = append(f.values, v)
+				rec.fields[h] = f
+				continue
+			}
+		}
+		rec.put(h, field{
 			value: v,
+		})
+	}
+	if r.FieldCount&FieldCountKeepExtra != 0 {
+		for i := len(r.header); i < len(record); i++ {
+			rec.put(r.layout.key(fmt.Sprintf("%s%d", ExtraFieldPrefix, i-len(r.header)+1)), field{
+				value: record[i],
+			})
+		}
+	}
+
+	rec.index = r.count
+	r.count++
+	return rec, record, nil
+}
+
+// readFields reads the fields of the next record with the underlying `encoding/csv.Reader`,
+// switching to the next member of the input if any.
+// Caller must hold the mutex.
+func (r *Reader) readFields() ([]string, error) {
+	for {
+		if r.FieldCount == FieldCountStrict {
+			r.reader.FieldsPerRecord = len(r.header)
+		} else {
+			r.reader.FieldsPerRecord = -1
+		}
+		record, err := r.reader.Read()
+		if err != io.EOF || r.nextMember == nil {
+			return record, err
+		}
+		if err := r.nextReader(); err != nil {
+			return nil, err
 		}
 	}
+}
 
-	return &Record{
-		fields: fields,
-	}, nil
+// configureCSVReader applies the WithCSVReader options to the given `encoding/csv.Reader`.
+func (r *Reader) configureCSVReader(cr *csv.Reader) {
+	for _, c := range r.configure {
+		c(cr)
+	}
+}
+
+// next reads the next valid record, rejecting the ones with a parse error if MaxErrors allows it.
+func (r *Reader) next() (*Record, []string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for {
+		record, raw, err := r.read()
+		var perr *csv.ParseError
+		if err == nil || r.MaxErrors == 0 || !errors.As(err, &perr) {
+			return record, raw, err
+		}
+		if err := r.reject(perr.StartLine, raw, err); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// reject adds the given record to the rejected ones and writes it to Rejects if set.
+// ErrTooManyErrors is returned if MaxErrors is exceeded.
+// Caller must hold the mutex.
+func (r *Reader) reject(line int, raw []string, cause error) error {
+	r.rejected = append(r.rejected, RejectedRecord{
+		Line:   line,
+		Fields: raw,
+		Err:    cause,
+	})
+	if r.Rejects != nil && raw != nil {
+		if err := r.Rejects.Write(raw); err != nil {
+			return fmt.Errorf("cannot write rejected record: %w", err)
+		}
+		r.Rejects.Flush()
+		if err := r.Rejects.Error(); err != nil {
+			return fmt.Errorf("cannot write rejected record: %w", err)
+		}
+	}
+	if r.MaxErrors > 0 && len(r.rejected) > r.MaxErrors {
+		return ErrTooManyErrors{max: r.MaxErrors, err: cause}
+	}
+	return nil
+}
+
+// rejectRecord rejects a record read by next if MaxErrors allows it, otherwise cause is returned.
+func (r *Reader) rejectRecord(record *Record, raw []string, cause error) error {
+	if r.MaxErrors == 0 {
+		return cause
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.reject(record.line, raw, cause)
+}
+
+// Rejected returns the records rejected so far by ReadAll, ReadAllInto, Each, All and TypedReader, see MaxErrors.
+func (r *Reader) Rejected() []RejectedRecord {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rejected := make([]RejectedRecord, len(r.rejected))
+	copy(rejected, r.rejected)
+	return rejected
+}
+
+// RejectTo sets Rejects with a new `encoding/csv.Writer` writing to w, using the same field delimiter as this Reader.
+func (r *Reader) RejectTo(w io.Writer) {
+	r.Rejects = csv.NewWriter(w)
+	r.Rejects.Comma = r.reader.Comma
 }
 
 // ReadAll ReadAll reads all the remaining records.
 //
 // As for the underlying `csv.Reader`, a successful call returns err == nil, not err == io.EOF.
 // Because ReadAll is defined to read until EOF, it does not treat end of file as an error to be reported.
+//
+// If MaxErrors is set, records with a parse error (*csv.ParseError, including csv.ErrFieldCount) are rejected
+// instead of aborting the reading, see Rejected.
 func (r *Reader) ReadAll() ([]*Record, error) {
 	var records []*Record
 	for {
-		record, err := r.Read()
+		record, _, err := r.next()
 		if err == io.EOF {
 			return records, nil
 		}
@@ -93,3 +341,90 @@ func (r *Reader) ReadAll() ([]*Record, error) {
 		records = append(records, record)
 	}
 }
+
+// Each reads all the remaining records and calls fn for each of them.
+//
+// It stops at the end of the input and returns nil, or at the first error returned by the reader or by fn.
+// If MaxErrors is set, records with a parse error or for which fn returns an error are rejected
+// instead of aborting the reading, see Rejected.
+func (r *Reader) Each(fn func(*Record) error) error {
+	for {
+		record, raw, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			if err := r.rejectRecord(record, raw, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ReadInto reads one record and stores it in the struct pointed to by dst.
+//
+// Struct fields are matched to columns with the `csv` struct tag, for instance `csv:"first_name"`.
+// Untagged exported fields are matched using the field name and fields tagged with `csv:"-"` are ignored.
+// The `format` tag option defines the layout used for time.Time fields, `csv:"updated,format=2006-01-02"`, time.RFC3339 is used otherwise.
+// Values are converted with the Record getters, thus a missing column returns ErrUnknownKey
+// and a value that cannot be converted returns ErrWrongType, both wrapped with the path of the struct field.
+//
+// If there is no data left to be read, ReadInto returns io.EOF.
+func (r *Reader) ReadInto(dst interface{}) error {
+	record, err := r.Read()
+	if err != nil {
+		return err
+	}
+	return record.unmarshal(dst)
+}
+
+// ReadAllInto reads all the remaining records and appends them to the slice pointed to by slicePtr.
+//
+// Slice elements can be either structs or pointers to structs, see ReadInto for the mapping rules.
+// As for ReadAll, a successful call returns err == nil, not err == io.EOF.
+// On error, the slice is left untouched.
+//
+// If MaxErrors is set, records with a parse error or that cannot be stored in a struct are rejected
+// instead of aborting the reading, see Rejected.
+func (r *Reader) ReadAllInto(slicePtr interface{}) error {
+	v := reflect.ValueOf(slicePtr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cannot read into %T, expecting a non-nil pointer to a slice", slicePtr)
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot read into %T, expecting a slice of structs", slicePtr)
+	}
+
+	result := slice
+	for {
+		record, raw, err := r.next()
+		if err == io.EOF {
+			slice.Set(result)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(elemType)
+		if err := record.unmarshal(elem.Interface()); err != nil {
+			if err := r.rejectRecord(record, raw, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if isPtr {
+			result = reflect.Append(result, elem)
+		} else {
+			result = reflect.Append(result, elem.Elem())
+		}
+	}
+}
@@ -162,3 +162,307 @@ func TestReadAll(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordPosition(t *testing.T) {
+	data := "first_name,age\nHolly,27\n\"Gia\ncobo\",abc\nAubrie,32"
+	reader, err := NewReader(csv.NewReader(bytes.NewBufferString(data)))
+	require.NoError(t, err)
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	for i, line := range []int{2, 3, 5} {
+		assert.Equal(t, line, records[i].Line())
+		assert.Equal(t, i, records[i].Index())
+	}
+
+	testcases := map[string]struct {
+		get      func(r *Record) error
+		column   int
+		key      string
+		errType  interface{}
+		expected string
+	}{
+		"wrong type": {
+			get: func(r *Record) error {
+				_, err := r.GetInt("age")
+				return err
+			},
+			column:   2,
+			key:      "age",
+			errType:  &ErrWrongType{},
+			expected: "record on line 3, column 2 (age): field with key 'age' is not the expected type",
+		},
+		"unknown key": {
+			get: func(r *Record) error {
+				_, err := r.Get("unknown")
+				return err
+			},
+			column:   0,
+			key:      "unknown",
+			errType:  &ErrUnknownKey{},
+			expected: "record on line 3: key 'unknown' does not exist",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			err := tc.get(records[1])
+			require.Error(t, err)
+			var perr *ParseError
+			require.True(t, errors.As(err, &perr))
+			assert.Equal(t, 3, perr.Line)
+			assert.Equal(t, tc.column, perr.Column)
+			assert.Equal(t, tc.key, perr.Key)
+			assert.True(t, errors.As(err, tc.errType))
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
+
+func TestReadFieldCount(t *testing.T) {
+	testcases := map[string]struct {
+		data     string
+		policy   FieldCountPolicy
+		expected map[string]string
+		missing  []string
+		err      bool
+	}{
+		"strict": {
+			data:     "a,b\n1,2",
+			expected: map[string]string{"a": "1", "b": "2"},
+		},
+		"strict missing": {
+			data: "a,b\n1",
+			err:  true,
+		},
+		"strict extra": {
+			data: "a,b\n1,2,3",
+			err:  true,
+		},
+		"pad": {
+			data:     "a,b,c\n1",
+			policy:   FieldCountPad,
+			expected: map[string]string{"a": "1", "b": "", "c": ""},
+		},
+		"pad extra": {
+			data:   "a,b\n1,2,3",
+			policy: FieldCountPad,
+			err:    true,
+		},
+		"truncate": {
+			data:     "a,b\n1,2,3",
+			policy:   FieldCountTruncate,
+			expected: map[string]string{"a": "1", "b": "2"},
+			missing:  []string{"_extra_1"},
+		},
+		"truncate missing": {
+			data:   "a,b\n1",
+			policy: FieldCountTruncate,
+			err:    true,
+		},
+		"keep extra": {
+			data:     "a,b\n1,2,3,4",
+			policy:   FieldCountKeepExtra | FieldCountTruncate,
+			expected: map[string]string{"a": "1", "b": "2", "_extra_1": "3", "_extra_2": "4"},
+		},
+		"pad and truncate": {
+			data:     "a,b\n1\n1,2,3",
+			policy:   FieldCountPad | FieldCountTruncate,
+			expected: map[string]string{"a": "1", "b": ""},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReader(csv.NewReader(bytes.NewBufferString(tc.data)))
+			require.NoError(t, err)
+			reader.FieldCount = tc.policy
+
+			record, err := reader.Read()
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, csv.ErrFieldCount))
+				var perr *csv.ParseError
+				require.True(t, errors.As(err, &perr))
+				assert.Equal(t, 2, perr.Line)
+				return
+			}
+			require.NoError(t, err)
+			for k, v := range tc.expected {
+				val, err := record.Get(k)
+				require.NoError(t, err)
+				assert.Equal(t, v, val)
+			}
+			for _, k := range tc.missing {
+				_, err := record.Get(k)
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestReadAllRejects(t *testing.T) {
+	data := "first_name,age\nHolly,27\nGiacobo\nAu\"brie,32\nKristoforo,59,extra\nJasmine,35"
+
+	testcases := map[string]struct {
+		maxErrors int
+		names     []string
+		lines     []int
+		rejects   string
+		err       bool
+		errType   interface{}
+	}{
+		"no limit": {
+			maxErrors: -1,
+			names:     []string{"Holly", "Jasmine"},
+			lines:     []int{3, 4, 5},
+			rejects:   "Giacobo\nKristoforo,59,extra\n",
+		},
+		"within limit": {
+			maxErrors: 3,
+			names:     []string{"Holly", "Jasmine"},
+			lines:     []int{3, 4, 5},
+			rejects:   "Giacobo\nKristoforo,59,extra\n",
+		},
+		"too many errors": {
+			maxErrors: 2,
+			lines:     []int{3, 4, 5},
+			rejects:   "Giacobo\nKristoforo,59,extra\n",
+			err:       true,
+			errType:   &ErrTooManyErrors{},
+		},
+		"disabled": {
+			lines: []int{},
+			err:   true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReader(csv.NewReader(bytes.NewBufferString(data)))
+			require.NoError(t, err)
+			reader.MaxErrors = tc.maxErrors
+			var rejects bytes.Buffer
+			reader.RejectTo(&rejects)
+
+			records, err := reader.ReadAll()
+			if tc.err {
+				require.Error(t, err)
+				if tc.errType != nil {
+					assert.True(t, errors.As(err, tc.errType))
+				}
+				assert.True(t, errors.Is(err, csv.ErrFieldCount))
+			} else {
+				require.NoError(t, err)
+				var names []string
+				for _, r := range records {
+					n, err := r.Get("first_name")
+					require.NoError(t, err)
+					names = append(names, n)
+				}
+				assert.Equal(t, tc.names, names)
+			}
+
+			lines := []int{}
+			for _, r := range reader.Rejected() {
+				lines = append(lines, r.Line)
+				assert.Error(t, r.Err)
+			}
+			assert.Equal(t, tc.lines, lines)
+			assert.Equal(t, tc.rejects, rejects.String())
+		})
+	}
+}
+
+func TestEach(t *testing.T) {
+	data := "first_name,age\nHolly,27\nGiacobo,abc\nAubrie,32"
+
+	testcases := map[string]struct {
+		maxErrors int
+		names     []string
+		rejected  int
+		err       bool
+	}{
+		"abort": {
+			names: []string{"Holly"},
+			err:   true,
+		},
+		"reject": {
+			maxErrors: 1,
+			names:     []string{"Holly", "Aubrie"},
+			rejected:  1,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReader(csv.NewReader(bytes.NewBufferString(data)))
+			require.NoError(t, err)
+			reader.MaxErrors = tc.maxErrors
+
+			var names []string
+			err = reader.Each(func(r *Record) error {
+				if _, err := r.GetInt("age"); err != nil {
+					return err
+				}
+				n, err := r.Get("first_name")
+				names = append(names, n)
+				return err
+			})
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &ErrWrongType{}))
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.names, names)
+			rejected := reader.Rejected()
+			require.Len(t, rejected, tc.rejected)
+			if tc.rejected > 0 {
+				assert.Equal(t, 3, rejected[0].Line)
+				assert.Equal(t, []string{"Giacobo", "abc"}, rejected[0].Fields)
+			}
+		})
+	}
+}
+
+func TestReadAllIntoRejects(t *testing.T) {
+	reader, err := NewReader(csv.NewReader(bytes.NewBufferString("first_name,age\nHolly,27\nGiacobo,abc\nAubrie,32")))
+	require.NoError(t, err)
+	reader.MaxErrors = -1
+
+	var people []struct {
+		FirstName string `csv:"first_name"`
+		Age       int    `csv:"age"`
+	}
+	require.NoError(t, reader.ReadAllInto(&people))
+	assert.Len(t, people, 2)
+	require.Len(t, reader.Rejected(), 1)
+	assert.True(t, errors.As(reader.Rejected()[0].Err, &ErrWrongType{}))
+}
+
+func TestReaderHeaderOrder(t *testing.T) {
+	data := "Last Name,first_name,age,age\nFranklin,Holly,27,28,extra\n"
+	reader, err := NewReaderWithOptions(csv.NewReader(bytes.NewBufferString(data)),
+		WithNormalizer(NormalizeCase, NormalizeSpaces),
+		WithDuplicates(DuplicateRename),
+	)
+	require.NoError(t, err)
+	reader.FieldCount = FieldCountKeepExtra
+	assert.Equal(t, []string{"last_name", "first_name", "age", "age_2"}, reader.Header())
+
+	record, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"last_name", "first_name", "age", "age_2", "_extra_1"}, record.Keys())
+	var values []string
+	record.Range(func(_, value string) bool {
+		values = append(values, value)
+		return true
+	})
+	assert.Equal(t, []string{"Franklin", "Holly", "27", "28", "extra"}, values)
+
+	w, err := NewWriter(csv.NewWriter(nil), reader.Header()...)
+	require.NoError(t, err)
+	assert.Equal(t, reader.Header(), w.Header())
+}
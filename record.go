@@ -3,6 +3,7 @@ package csvhandler
 import (
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"strconv"
 	"time"
@@ -12,11 +13,25 @@ import (
 // It offers utility functions to access field based on the column name
 type Record struct {
 	fields map[string]field
+	keys   []string // Keys in header order for records read by a Reader, in insertion order otherwise
+	line   int      // Line of the record in the input, 0 if the record has not been read by a Reader
+	index  int      // Index of the record among the records read by a Reader
+	layout *layout  // Columns of the Reader, nil if the record has not been read by a Reader
 }
 
 type field struct {
 	value     interface{}
 	formatter Formatter
+	values    []string // All the values of a duplicate column, see DuplicateMulti
+}
+
+// String returns the value of the field as a string, formatted with defaultFormatter if needed.
+func (f field) String() string {
+	if s, ok := f.value.(string); ok {
+		return s
+	}
+	s, _ := defaultFormatter(f.value)
+	return s
 }
 
 // NewRecord returns a new empty Record.
@@ -26,6 +41,18 @@ func NewRecord() *Record {
 	}
 }
 
+// Line returns the line where the record starts in the input, starting at 1.
+// It returns 0 if the record has not been read by a Reader.
+func (r *Record) Line() int {
+	return r.line
+}
+
+// Index returns the index of the record among the records read by the Reader, starting at 0 for the first record after the header.
+// It is only meaningful for records read by a Reader, see Line.
+func (r *Record) Index() int {
+	return r.index
+}
+
 // Set sets the given value to the given key.
 //
 // Calling twice this function with the same key will override the value.
@@ -40,10 +67,165 @@ func (r *Record) Set(key string, value interface{}, formatter ...Formatter) {
 	} else if len(formatter) > 1 {
 		f = chainFormatter(formatter...)
 	}
-	r.fields[key] = field{
+	r.put(r.layout.key(key), field{
 		value:     value,
 		formatter: f,
+	})
+}
+
+// put sets the field of the given key, appending the key if new.
+func (r *Record) put(key string, f field) {
+	if _, exists := r.fields[key]; !exists {
+		r.keys = append(r.keys, key)
+	}
+	r.fields[key] = f
+}
+
+// Keys returns the keys of the record, in header order for records read by a Reader,
+// in the order they were first set otherwise.
+func (r *Record) Keys() []string {
+	keys := make([]string, len(r.keys))
+	copy(keys, r.keys)
+	return keys
+}
+
+// Len returns the number of fields of the record.
+func (r *Record) Len() int {
+	return len(r.keys)
+}
+
+// Range calls fn for each field of the record, in the order of Keys, with its value as returned by Get.
+// If fn returns false, Range stops the iteration.
+func (r *Record) Range(fn func(key, value string) bool) {
+	for _, k := range r.keys {
+		if !fn(k, r.fields[k].String()) {
+			return
+		}
+	}
+}
+
+// MergePolicy defines how Record.Merge handles keys present in both records.
+type MergePolicy int
+
+const (
+	// MergeError returns ErrDuplicateKey if a key is present in both records, this is the default policy.
+	MergeError MergePolicy = iota
+	// MergeOverwrite uses the fields of the merged record.
+	MergeOverwrite
+	// MergeKeep keeps the fields of the record.
+	MergeKeep
+)
+
+// Has returns whether the record has a field for the given key.
+func (r *Record) Has(key string) bool {
+	_, ok := r.field(key)
+	return ok
+}
+
+// Delete removes the field of the given key, if any.
+func (r *Record) Delete(key string) {
+	k := r.layout.key(key)
+	if _, ok := r.fields[k]; !ok {
+		return
+	}
+	delete(r.fields, k)
+	for i, rk := range r.keys {
+		if rk == k {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Rename renames the field of the given key, keeping its value, formatter and position.
+// If the key is missing, ErrUnknownKey is returned. If the new key already exists, ErrDuplicateKey is returned.
+func (r *Record) Rename(oldKey, newKey string) error {
+	o, n := r.layout.key(oldKey), r.layout.key(newKey)
+	f, ok := r.fields[o]
+	if !ok {
+		return r.wrapErr(oldKey, ErrUnknownKey{key: oldKey})
+	}
+	if o == n {
+		return nil
+	}
+	if _, exists := r.fields[n]; exists {
+		return ErrDuplicateKey{key: newKey}
+	}
+	delete(r.fields, o)
+	r.fields[n] = f
+	for i, k := range r.keys {
+		if k == o {
+			r.keys[i] = n
+			break
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of the record, with the same fields, formatters and position.
+func (r *Record) Clone() *Record {
+	c := &Record{
+		fields: make(map[string]field, len(r.fields)),
+		keys:   make([]string, len(r.keys)),
+		line:   r.line,
+		index:  r.index,
+		layout: r.layout,
+	}
+	copy(c.keys, r.keys)
+	for k, f := range r.fields {
+		if f.values != nil {
+			f.values = append([]string(nil), f.values...)
+		}
+		c.fields[k] = f
+	}
+	return c
+}
+
+// Merge adds the fields of other to the record, with their formatters, in the order of other.Keys.
+// Keys present in both records are handled according to the given policy,
+// with MergeError nothing is merged if a duplicate is found.
+func (r *Record) Merge(other *Record, policy MergePolicy) error {
+	if policy == MergeError {
+		for _, k := range other.keys {
+			if _, exists := r.fields[r.layout.key(k)]; exists {
+				return ErrDuplicateKey{key: k}
+			}
+		}
+	}
+	for _, k := range other.keys {
+		key := r.layout.key(k)
+		if _, exists := r.fields[key]; exists && policy == MergeKeep {
+			continue
+		}
+		r.put(key, other.fields[k])
+	}
+	return nil
+}
+
+// Project returns a new record with only the fields of the given keys, in the given order.
+// If some keys are missing, ErrUnknownKey listing all of them is returned.
+func (r *Record) Project(keys ...string) (*Record, error) {
+	p := &Record{
+		fields: make(map[string]field, len(keys)),
+		keys:   make([]string, 0, len(keys)),
+		line:   r.line,
+		index:  r.index,
+		layout: r.layout,
+	}
+	var missing []string
+	for _, key := range keys {
+		k := r.layout.key(key)
+		f, ok := r.fields[k]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		p.put(k, f)
 	}
+	if len(missing) > 0 {
+		return nil, r.wrapErr(missing[0], ErrUnknownKey{key: missing[0], keys: missing})
+	}
+	return p, nil
 }
 
 // Fprintln prints into the given writer each given column with a 'key=value' format.
@@ -77,16 +259,31 @@ func (r *Record) Println(columns ...string) error {
 // Get returns as a string the field corresponding to the given key.
 // If the key is missing, ErrUnknownKey is returned.
 func (r *Record) Get(key string) (string, error) {
-	f, ok := r.fields[key]
+	f, ok := r.field(key)
 	if !ok {
-		return "", ErrUnknownKey{key}
+		return "", r.wrapErr(key, ErrUnknownKey{key: key})
 	}
-	switch v := f.value.(type) {
-	case string:
-		return string(v), nil
-	default:
-		return defaultFormatter(v)
+	return f.String(), nil
+}
+
+// GetAll returns all the values of the fields corresponding to the given key.
+// Multiple values are only returned for duplicate columns read with the DuplicateMulti policy, see WithDuplicates.
+// If the key is missing, ErrUnknownKey is returned.
+func (r *Record) GetAll(key string) ([]string, error) {
+	f, ok := r.field(key)
+	if !ok {
+		return nil, r.wrapErr(key, ErrUnknownKey{key: key})
+	}
+	if f.values != nil {
+		values := make([]string, len(f.values))
+		copy(values, f.values)
+		return values, nil
+	}
+	v, err := r.Get(key)
+	if err != nil {
+		return nil, err
 	}
+	return []string{v}, nil
 }
 
 // GetBool returns as a boolean the field corresponding to the given key.
@@ -98,7 +295,7 @@ func (r *Record) GetBool(key string) (bool, error) {
 		return false, err
 	}
 	if v != "true" && v != "false" {
-		return false, ErrWrongType{key: key, err: fmt.Errorf("'%s' is not a boolean", v)}
+		return false, r.wrapErr(key, ErrWrongType{key: key, err: fmt.Errorf("'%s' is not a boolean", v)})
 	}
 	return v == "true", nil
 }
@@ -107,29 +304,82 @@ func (r *Record) GetBool(key string) (bool, error) {
 // If the key is missing, ErrUnknownKey is returned.
 // If the field is not the expected type, ErrWrongType is returned.
 func (r *Record) GetInt(key string) (int, error) {
-	i, err := r.GetInt64(key)
-	if err != nil {
-		return 0, err
-	}
-	return int(i), nil
+	i, err := r.parseInt(key, strconv.IntSize)
+	return int(i), err
+}
+
+// GetInt8 returns as an integer8 the field corresponding to the given key.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type or overflows, ErrWrongType is returned.
+func (r *Record) GetInt8(key string) (int8, error) {
+	i, err := r.parseInt(key, 8)
+	return int8(i), err
+}
+
+// GetInt16 returns as an integer16 the field corresponding to the given key.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type or overflows, ErrWrongType is returned.
+func (r *Record) GetInt16(key string) (int16, error) {
+	i, err := r.parseInt(key, 16)
+	return int16(i), err
+}
+
+// GetInt32 returns as an integer32 the field corresponding to the given key.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type or overflows, ErrWrongType is returned.
+func (r *Record) GetInt32(key string) (int32, error) {
+	i, err := r.parseInt(key, 32)
+	return int32(i), err
 }
 
 // GetInt64 returns as an integer64 the field corresponding to the given key.
 // If the key is missing, ErrUnknownKey is returned.
 // If the field is not the expected type, ErrWrongType is returned.
 func (r *Record) GetInt64(key string) (int64, error) {
+	return r.parseInt(key, 64)
+}
+
+// GetUint returns as an unsigned integer the field corresponding to the given key.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type or overflows, ErrWrongType is returned.
+func (r *Record) GetUint(key string) (uint, error) {
+	u, err := r.parseUint(key, strconv.IntSize)
+	return uint(u), err
+}
+
+// GetUint64 returns as an unsigned integer64 the field corresponding to the given key.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type or overflows, ErrWrongType is returned.
+func (r *Record) GetUint64(key string) (uint64, error) {
+	return r.parseUint(key, 64)
+}
+
+// parseInt returns as an integer of the given bit size the field corresponding to the given key.
+func (r *Record) parseInt(key string, bitSize int) (int64, error) {
 	v, err := r.Get(key)
 	if err != nil {
 		return 0, err
 	}
-	i, err := strconv.ParseInt(v, 10, 64)
+	i, err := strconv.ParseInt(v, 10, bitSize)
 	if err != nil {
-		return 0, ErrWrongType{key: key, err: err}
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
 	}
-
 	return i, nil
 }
 
+// parseUint returns as an unsigned integer of the given bit size the field corresponding to the given key.
+func (r *Record) parseUint(key string, bitSize int) (uint64, error) {
+	v, err := r.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	u, err := strconv.ParseUint(v, 10, bitSize)
+	if err != nil {
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
+	}
+	return u, nil
+}
+
 // GetFloat64 returns as an float the field corresponding to the given key.
 // If the key is missing, ErrUnknownKey is returned.
 // If the field is not the expected type, ErrWrongType is returned.
@@ -140,11 +390,79 @@ func (r *Record) GetFloat64(key string) (float64, error) {
 	}
 	f, err := strconv.ParseFloat(v, 64)
 	if err != nil {
-		return 0, ErrWrongType{key: key, err: err}
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
+	}
+	return f, nil
+}
+
+// GetFloat32 returns as a float32 the field corresponding to the given key.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type or overflows, ErrWrongType is returned.
+func (r *Record) GetFloat32(key string) (float32, error) {
+	v, err := r.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(v, 32)
+	if err != nil {
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
+	}
+	return float32(f), nil
+}
+
+// GetBigInt returns as an arbitrary-precision integer the field corresponding to the given key.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetBigInt(key string) (*big.Int, error) {
+	v, err := r.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := new(big.Int).SetString(v, 10)
+	if !ok {
+		return nil, r.wrapErr(key, ErrWrongType{key: key, err: fmt.Errorf("'%s' is not an integer", v)})
+	}
+	return i, nil
+}
+
+// GetBigFloat returns as an arbitrary-precision float the field corresponding to the given key.
+// The precision is large enough to hold all the digits of the field, at least 64 bits.
+// Decimal fractions are still rounded to binary, use GetRat for exact amounts.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetBigFloat(key string) (*big.Float, error) {
+	v, err := r.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	// A decimal digit needs log2(10) bits, less than 4
+	prec := uint(4 * len(v))
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(v, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, r.wrapErr(key, ErrWrongType{key: key, err: err})
 	}
 	return f, nil
 }
 
+// GetRat returns as an exact rational number the field corresponding to the given key,
+// for instance 1234.56 is 123456/100.
+// If the key is missing, ErrUnknownKey is returned.
+// If the field is not the expected type, ErrWrongType is returned.
+func (r *Record) GetRat(key string) (*big.Rat, error) {
+	v, err := r.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	q, ok := new(big.Rat).SetString(v)
+	if !ok {
+		return nil, r.wrapErr(key, ErrWrongType{key: key, err: fmt.Errorf("'%s' is not a number", v)})
+	}
+	return q, nil
+}
+
 // GetTime returns as a time.Time the field corresponding to the given key.
 // If the key is missing, ErrUnknownKey is returned.
 // If the field cannot  be parsed as a time using the given layout, ErrWrongType is returned.
@@ -156,7 +474,7 @@ func (r *Record) GetTime(layout, key string) (time.Time, error) {
 
 	t, err := time.Parse(layout, v)
 	if err != nil {
-		return time.Unix(0, 0), ErrWrongType{key: key, err: err}
+		return time.Unix(0, 0), r.wrapErr(key, ErrWrongType{key: key, err: err})
 	}
 	return t, nil
 }
@@ -172,7 +490,32 @@ func (r *Record) GetDuration(key string) (time.Duration, error) {
 
 	d, err := time.ParseDuration(v)
 	if err != nil {
-		return 0, ErrWrongType{key: key, err: err}
+		return 0, r.wrapErr(key, ErrWrongType{key: key, err: err})
 	}
 	return d, nil
 }
+
+// field returns the field with the given key, normalized as the Reader column names if any.
+func (r *Record) field(key string) (field, bool) {
+	f, ok := r.fields[r.layout.key(key)]
+	return f, ok
+}
+
+// wrapErr wraps the given error in a ParseError if this record has been read by a Reader.
+func (r *Record) wrapErr(key string, err error) error {
+	if r.line == 0 {
+		return err
+	}
+	column := 0
+	if r.layout != nil {
+		if i, ok := r.layout.columns[r.layout.key(key)]; ok {
+			column = i + 1
+		}
+	}
+	return &ParseError{
+		Line:   r.line,
+		Column: column,
+		Key:    key,
+		Err:    err,
+	}
+}
@@ -2,6 +2,7 @@ package csvhandler
 
 import (
 	"bytes"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
@@ -258,6 +259,136 @@ func TestGetFloat64(t *testing.T) {
 	}
 }
 
+func TestGetSizedNumbers(t *testing.T) {
+	record := NewRecord()
+	record.Set("small", 100)
+	record.Set("medium", 30000)
+	record.Set("large", 3000000000)
+	record.Set("negative", -1)
+	record.Set("max_uint64", "18446744073709551615")
+	record.Set("float", 15.5)
+	record.Set("huge_float", "1e40")
+	record.Set("text", "abc")
+
+	testcases := map[string]struct {
+		get      func(key string) (interface{}, error)
+		key      string
+		expected interface{}
+		errType  interface{}
+	}{
+		"int8": {
+			get:      func(key string) (interface{}, error) { return record.GetInt8(key) },
+			key:      "small",
+			expected: int8(100),
+		},
+		"int8 overflow": {
+			get:     func(key string) (interface{}, error) { return record.GetInt8(key) },
+			key:     "medium",
+			errType: &ErrWrongType{},
+		},
+		"int16": {
+			get:      func(key string) (interface{}, error) { return record.GetInt16(key) },
+			key:      "medium",
+			expected: int16(30000),
+		},
+		"int16 overflow": {
+			get:     func(key string) (interface{}, error) { return record.GetInt16(key) },
+			key:     "large",
+			errType: &ErrWrongType{},
+		},
+		"int32": {
+			get:      func(key string) (interface{}, error) { return record.GetInt32(key) },
+			key:      "negative",
+			expected: int32(-1),
+		},
+		"int32 overflow": {
+			get:     func(key string) (interface{}, error) { return record.GetInt32(key) },
+			key:     "large",
+			errType: &ErrWrongType{},
+		},
+		"uint": {
+			get:      func(key string) (interface{}, error) { return record.GetUint(key) },
+			key:      "small",
+			expected: uint(100),
+		},
+		"uint negative": {
+			get:     func(key string) (interface{}, error) { return record.GetUint(key) },
+			key:     "negative",
+			errType: &ErrWrongType{},
+		},
+		"uint64": {
+			get:      func(key string) (interface{}, error) { return record.GetUint64(key) },
+			key:      "max_uint64",
+			expected: uint64(18446744073709551615),
+		},
+		"uint64 not a number": {
+			get:     func(key string) (interface{}, error) { return record.GetUint64(key) },
+			key:     "text",
+			errType: &ErrWrongType{},
+		},
+		"float32": {
+			get:      func(key string) (interface{}, error) { return record.GetFloat32(key) },
+			key:      "float",
+			expected: float32(15.5),
+		},
+		"float32 overflow": {
+			get:     func(key string) (interface{}, error) { return record.GetFloat32(key) },
+			key:     "huge_float",
+			errType: &ErrWrongType{},
+		},
+		"unknown key": {
+			get:     func(key string) (interface{}, error) { return record.GetInt32(key) },
+			key:     "unknown",
+			errType: &ErrUnknownKey{},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			val, err := tc.get(tc.key)
+			if tc.errType != nil {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, tc.errType))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, val)
+			}
+		})
+	}
+}
+
+func TestGetBigNumbers(t *testing.T) {
+	record := NewRecord()
+	record.Set("balance", "123456789012345678901234567890.12")
+	record.Set("count", "123456789012345678901234567890")
+	record.Set("ratio", "1/3")
+	record.Set("text", "abc")
+
+	i, err := record.GetBigInt("count")
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012345678901234567890", i.String())
+	_, err = record.GetBigInt("balance")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+
+	f, err := record.GetBigFloat("balance")
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012345678901234567890.12", f.Text('f', 2))
+	_, err = record.GetBigFloat("text")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+
+	q, err := record.GetRat("balance")
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012345678901234567890.12", q.FloatString(2))
+	q, err = record.GetRat("ratio")
+	require.NoError(t, err)
+	assert.Equal(t, "1/3", q.String())
+	_, err = record.GetRat("text")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+
+	_, err = record.GetBigInt("unknown")
+	assert.True(t, errors.As(err, &ErrUnknownKey{}))
+}
+
 func TestGetTime(t *testing.T) {
 	testcases := map[string]struct {
 		key      string
@@ -374,3 +505,156 @@ func TestSet(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordKeys(t *testing.T) {
+	r := NewRecord()
+	r.Set("last_name", "Franklin")
+	r.Set("first_name", "Holly")
+	r.Set("age", 27)
+	r.Set("last_name", "Smith")
+
+	assert.Equal(t, []string{"last_name", "first_name", "age"}, r.Keys())
+	assert.Equal(t, 3, r.Len())
+
+	var values []string
+	r.Range(func(key, value string) bool {
+		values = append(values, key+"="+value)
+		return true
+	})
+	assert.Equal(t, []string{"last_name=Smith", "first_name=Holly", "age=27"}, values)
+
+	values = nil
+	r.Range(func(key, value string) bool {
+		values = append(values, key)
+		return len(values) < 2
+	})
+	assert.Equal(t, []string{"last_name", "first_name"}, values)
+
+	// Keys returns a copy
+	r.Keys()[0] = "foo"
+	assert.Equal(t, "last_name", r.Keys()[0])
+	assert.Equal(t, 0, NewRecord().Len())
+}
+
+func TestRecordEdit(t *testing.T) {
+	newRecord := func() *Record {
+		r := NewRecord()
+		r.Set("first_name", "Holly")
+		r.Set("last_name", "Franklin", StringFormatter("<%s>"))
+		r.Set("age", 27)
+		return r
+	}
+
+	r := newRecord()
+	assert.True(t, r.Has("age"))
+	assert.False(t, r.Has("city"))
+	r.Delete("age")
+	r.Delete("city")
+	assert.False(t, r.Has("age"))
+	assert.Equal(t, []string{"first_name", "last_name"}, r.Keys())
+
+	r = newRecord()
+	require.NoError(t, r.Rename("last_name", "name"))
+	require.NoError(t, r.Rename("age", "age"))
+	assert.Equal(t, []string{"first_name", "name", "age"}, r.Keys())
+	assert.True(t, errors.As(r.Rename("city", "town"), &ErrUnknownKey{}))
+	assert.True(t, errors.As(r.Rename("first_name", "age"), &ErrDuplicateKey{}))
+
+	// Formatter is kept
+	var b bytes.Buffer
+	w, err := NewWriter(csv.NewWriter(&b), "name", "first_name")
+	require.NoError(t, err)
+	require.NoError(t, w.Write(r))
+	assert.Equal(t, "<Franklin>,Holly\n", b.String())
+
+	r = newRecord()
+	c := r.Clone()
+	c.Set("first_name", "Giacobo")
+	c.Set("city", "Paris")
+	v, err := r.Get("first_name")
+	require.NoError(t, err)
+	assert.Equal(t, "Holly", v)
+	assert.Equal(t, []string{"first_name", "last_name", "age"}, r.Keys())
+	assert.Equal(t, []string{"first_name", "last_name", "age", "city"}, c.Keys())
+}
+
+func TestRecordMerge(t *testing.T) {
+	testcases := map[string]struct {
+		policy   MergePolicy
+		expected map[string]string
+		err      bool
+	}{
+		"error": {
+			policy: MergeError,
+			err:    true,
+		},
+		"overwrite": {
+			policy: MergeOverwrite,
+			expected: map[string]string{
+				"first_name": "Giacobo",
+				"age":        "20",
+				"city":       "Paris",
+			},
+		},
+		"keep": {
+			policy: MergeKeep,
+			expected: map[string]string{
+				"first_name": "Holly",
+				"age":        "27",
+				"city":       "Paris",
+			},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			r := NewRecord()
+			r.Set("first_name", "Holly")
+			r.Set("age", 27)
+			other := NewRecord()
+			other.Set("city", "Paris")
+			other.Set("first_name", "Giacobo")
+			other.Set("age", 20)
+
+			err := r.Merge(other, tc.policy)
+			if tc.err {
+				require.Error(t, err)
+				assert.True(t, errors.As(err, &ErrDuplicateKey{}))
+				assert.Equal(t, []string{"first_name", "age"}, r.Keys())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"first_name", "age", "city"}, r.Keys())
+			for k, expected := range tc.expected {
+				v, err := r.Get(k)
+				require.NoError(t, err)
+				assert.Equal(t, expected, v)
+			}
+		})
+	}
+
+	r := NewRecord()
+	r.Set("first_name", "Holly")
+	other := NewRecord()
+	other.Set("city", "Paris")
+	require.NoError(t, r.Merge(other, MergeError))
+	assert.Equal(t, []string{"first_name", "city"}, r.Keys())
+}
+
+func TestRecordProject(t *testing.T) {
+	reader, err := NewReaderWithOptions(csv.NewReader(bytes.NewBufferString("First Name,Last Name,Age\nHolly,Franklin,27\n")),
+		WithNormalizer(NormalizeCase, NormalizeSpaces))
+	require.NoError(t, err)
+	r, err := reader.Read()
+	require.NoError(t, err)
+
+	p, err := r.Project("Age", "first_name")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"age", "first_name"}, p.Keys())
+	assert.Equal(t, r.Line(), p.Line())
+
+	_, err = r.Project("age", "city", "country")
+	var uerr ErrUnknownKey
+	require.True(t, errors.As(err, &uerr))
+	assert.Equal(t, []string{"city", "country"}, uerr.Keys())
+}
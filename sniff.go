@@ -0,0 +1,228 @@
+package csvhandler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// sniffSize is the size of the sample inspected to detect a dialect.
+const sniffSize = 64 * 1024
+
+// sniffRecords is the maximum number of records of the sample inspected to detect a dialect.
+const sniffRecords = 100
+
+// sniffDelimiters are the candidate field delimiters, in order of preference.
+var sniffDelimiters = []rune{',', ';', '\t', '|'}
+
+// Dialect describes the format of a CSV input, see Sniff.
+type Dialect struct {
+	Comma          rune   // Field delimiter
+	Quoted         bool   // Whether some fields are enclosed in double quotes
+	HasHeader      bool   // Whether the first record is a header
+	LineTerminator string // Line terminator, either "\n" or "\r\n"
+	fields         int    // Number of fields of the first record
+}
+
+// ConfigureReader sets the given `encoding/csv.Reader` to read this dialect.
+// If fields are not quoted, LazyQuotes is enabled so that quotes within fields are kept as is.
+func (d Dialect) ConfigureReader(r *csv.Reader) {
+	r.Comma = d.Comma
+	r.LazyQuotes = !d.Quoted
+}
+
+// ConfigureWriter sets the given `encoding/csv.Writer` to write this dialect.
+func (d Dialect) ConfigureWriter(w *csv.Writer) {
+	w.Comma = d.Comma
+	w.UseCRLF = d.LineTerminator == "\r\n"
+}
+
+// Sniff inspects a sample of the given reader to detect the dialect of the CSV input:
+// field delimiter (among ',', ';', '\t' and '|'), quote usage, presence of a header and line terminator.
+//
+// Sniff consumes the sample from r, use NewReaderAuto to read the records after sniffing.
+func Sniff(r io.Reader) (Dialect, error) {
+	sample, err := io.ReadAll(io.LimitReader(r, sniffSize))
+	if err != nil {
+		return Dialect{}, err
+	}
+	return sniff(sample, len(sample) == sniffSize)
+}
+
+// NewReaderAuto creates a new Reader from the given reader, configured with the sniffed dialect (see Sniff).
+//
+// If a header is detected, column names are read from the first record,
+// otherwise columns are named `column_1`, `column_2`... unless a header is given with the WithHeader option.
+// Other options are the same as for NewReaderWithOptions.
+// Content is decoded according to its byte order mark or the WithEncoding option.
+func NewReaderAuto(r io.Reader, opts ...ReaderOption) (*Reader, error) {
+	reader := applyOptions(opts)
+	br := bufio.NewReaderSize(reader.decode(r), sniffSize)
+	sample, err := br.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	dialect, err := sniff(sample, len(sample) == sniffSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(br)
+	dialect.ConfigureReader(cr)
+	if !dialect.HasHeader && len(reader.header) == 0 {
+		reader.header = make([]string, dialect.fields)
+		for i := range reader.header {
+			reader.header[i] = fmt.Sprintf("column_%d", i+1)
+		}
+	}
+	return newReader(cr, reader)
+}
+
+// sniff detects the dialect of the given sample.
+// If truncated, the last line of the sample is ignored as it may be incomplete.
+func sniff(sample []byte, truncated bool) (Dialect, error) {
+	if truncated {
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
+	if len(bytes.TrimSpace(sample)) == 0 {
+		return Dialect{}, io.EOF
+	}
+
+	d := Dialect{
+		Comma:          ',',
+		LineTerminator: "\n",
+	}
+	if i := bytes.IndexByte(sample, '\n'); i > 0 && sample[i-1] == '\r' {
+		d.LineTerminator = "\r\n"
+	}
+
+	var records [][]string
+	bestScore, bestFields := 0, 0
+	for _, comma := range sniffDelimiters {
+		candidates := sniffRecordsWith(sample, comma)
+		if len(candidates) == 0 {
+			continue
+		}
+		// Score is the number of records having the most frequent number of fields
+		frequencies := make(map[int]int)
+		for _, c := range candidates {
+			frequencies[len(c)]++
+		}
+		score, fields := 0, 0
+		for n, f := range frequencies {
+			if f > score || f == score && n > fields {
+				score, fields = f, n
+			}
+		}
+		if fields < 2 {
+			continue
+		}
+		if score > bestScore || score == bestScore && fields > bestFields {
+			bestScore, bestFields = score, fields
+			d.Comma = comma
+			records = candidates
+		}
+	}
+	if records == nil {
+		records = sniffRecordsWith(sample, d.Comma)
+	}
+	if len(records) > 0 {
+		d.fields = len(records[0])
+	}
+	d.Quoted = sniffQuoted(sample, d.Comma)
+	d.HasHeader = sniffHeader(records)
+	return d, nil
+}
+
+// sniffRecordsWith parses the records of the sample with the given delimiter.
+// Parsing stops at the first error.
+func sniffRecordsWith(sample []byte, comma rune) [][]string {
+	r := csv.NewReader(bytes.NewReader(sample))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	var records [][]string
+	for len(records) < sniffRecords {
+		record, err := r.Read()
+		if err != nil {
+			break
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// sniffQuoted returns whether a field of the sample starts with a double quote.
+func sniffQuoted(sample []byte, comma rune) bool {
+	for _, line := range bytes.Split(sample, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte(`"`)) || bytes.Contains(line, []byte(string(comma)+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffHeader returns whether the first record looks like a header.
+//
+// Each column whose values are all numeric or all of the same length votes for a header if the first value differs,
+// against otherwise. Columns with heterogeneous values do not vote.
+// Without votes, for instance with a single record, the first record is considered as a header
+// if none of its values is empty or numeric.
+func sniffHeader(records [][]string) bool {
+	if len(records) == 0 {
+		return false
+	}
+	header := records[0]
+
+	votes := 0
+	for i, h := range header {
+		numeric, length, count := true, -1, 0
+		for _, record := range records[1:] {
+			if i >= len(record) {
+				continue
+			}
+			count++
+			numeric = numeric && isNumeric(record[i])
+			if length == -1 {
+				length = len(record[i])
+			} else if length != len(record[i]) {
+				length = -2
+			}
+		}
+		switch {
+		case count == 0:
+		case numeric:
+			if isNumeric(h) {
+				votes--
+			} else {
+				votes++
+			}
+		case length >= 0:
+			if len(h) == length {
+				votes--
+			} else {
+				votes++
+			}
+		}
+	}
+	if votes == 0 {
+		for _, h := range header {
+			if h == "" || isNumeric(h) {
+				return false
+			}
+		}
+		return true
+	}
+	return votes > 0
+}
+
+// isNumeric returns whether the given value is a number.
+func isNumeric(v string) bool {
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
@@ -0,0 +1,158 @@
+package csvhandler
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniff(t *testing.T) {
+	testcases := map[string]struct {
+		data     string
+		expected Dialect
+		err      bool
+	}{
+		"comma with header": {
+			data:     "first_name,last_name,age\nHolly,Franklin,27\nGiacobo,Tolumello,18\n",
+			expected: Dialect{Comma: ',', HasHeader: true, LineTerminator: "\n", fields: 3},
+		},
+		"semicolon crlf": {
+			data:     "first_name;age\r\nHolly;27\r\nGiacobo;18\r\n",
+			expected: Dialect{Comma: ';', HasHeader: true, LineTerminator: "\r\n", fields: 2},
+		},
+		"tab quoted": {
+			data:     "name\tcomment\n\"Holly\"\t\"a, b; c\"\n\"Giacobo\"\t\"d, e; f\"\n",
+			expected: Dialect{Comma: '\t', Quoted: true, HasHeader: false, LineTerminator: "\n", fields: 2},
+		},
+		"pipe without header": {
+			data:     "1|Holly|27\n2|Giacobo|18\n3|Aubrie|32\n",
+			expected: Dialect{Comma: '|', HasHeader: false, LineTerminator: "\n", fields: 3},
+		},
+		"single column": {
+			data:     "name\nHolly\nGiacobo\n",
+			expected: Dialect{Comma: ',', HasHeader: true, LineTerminator: "\n", fields: 1},
+		},
+		"single header line": {
+			data:     "first_name;last_name",
+			expected: Dialect{Comma: ';', HasHeader: true, LineTerminator: "\n", fields: 2},
+		},
+		"single data line": {
+			data:     "Holly;27",
+			expected: Dialect{Comma: ';', HasHeader: false, LineTerminator: "\n", fields: 2},
+		},
+		"empty": {
+			data: "",
+			err:  true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			d, err := Sniff(strings.NewReader(tc.data))
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, d)
+			}
+		})
+	}
+}
+
+func TestSniffFile(t *testing.T) {
+	f, err := os.Open(filepath.Join("tstdata", "regular.csv"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	d, err := Sniff(f)
+	require.NoError(t, err)
+	assert.Equal(t, ',', d.Comma)
+	assert.True(t, d.HasHeader)
+}
+
+func TestSniffTruncated(t *testing.T) {
+	data := "a;b\n" + strings.Repeat("1;2\n", sniffSize/4) + "1,2,3,4,5,6,7,8\n"
+	d, err := Sniff(strings.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, ';', d.Comma)
+}
+
+func TestNewReaderAuto(t *testing.T) {
+	testcases := map[string]struct {
+		data   string
+		opts   []ReaderOption
+		header []string
+		get    map[string]string
+		err    bool
+	}{
+		"with header": {
+			data:   "first_name;age\nHolly;27\nGiacobo;18",
+			header: []string{"first_name", "age"},
+			get:    map[string]string{"first_name": "Holly", "age": "27"},
+		},
+		"without header": {
+			data:   "1|Holly|27\n2|Giacobo|18",
+			header: []string{"column_1", "column_2", "column_3"},
+			get:    map[string]string{"column_2": "Holly"},
+		},
+		"given header": {
+			data:   "1|Holly|27\n2|Giacobo|18",
+			opts:   []ReaderOption{WithHeader("id", "first_name", "age")},
+			header: []string{"id", "first_name", "age"},
+			get:    map[string]string{"first_name": "Holly"},
+		},
+		"unquoted": {
+			data:   "name,comment\nHolly,6\" tall\nGiacobo,5.5\" tall",
+			header: []string{"name", "comment"},
+			get:    map[string]string{"comment": "6\" tall"},
+		},
+		"empty": {
+			err: true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReaderAuto(strings.NewReader(tc.data), tc.opts...)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.header, reader.header)
+			record, err := reader.Read()
+			require.NoError(t, err)
+			for k, v := range tc.get {
+				val, err := record.Get(k)
+				require.NoError(t, err)
+				assert.Equal(t, v, val)
+			}
+		})
+	}
+}
+
+func TestNewReaderAutoReadError(t *testing.T) {
+	_, err := NewReaderAuto(tstReader{err: errors.New("read error")})
+	require.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func TestDialectConfigure(t *testing.T) {
+	d := Dialect{Comma: ';', LineTerminator: "\r\n"}
+	r := csv.NewReader(nil)
+	d.ConfigureReader(r)
+	assert.Equal(t, ';', r.Comma)
+	assert.True(t, r.LazyQuotes)
+
+	w := csv.NewWriter(nil)
+	d.ConfigureWriter(w)
+	assert.Equal(t, ';', w.Comma)
+	assert.True(t, w.UseCRLF)
+}
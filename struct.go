@@ -0,0 +1,358 @@
+package csvhandler
+
+import (
+	"database/sql"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tagName is the struct tag key used to bind struct fields to columns.
+const tagName = "csv"
+
+// defaultTimeLayout is the layout used for time.Time fields without `format` tag option.
+const defaultTimeLayout = time.RFC3339
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	nullTimeType        = reflect.TypeOf(sql.NullTime{})
+)
+
+// structField describes how a struct field is bound to a column.
+type structField struct {
+	key       string // Column name
+	path      string // Go path of the field (e.g. Base.ID), used in errors
+	index     []int  // Index sequence for reflect.Value.FieldByIndex
+	typ       reflect.Type
+	omitEmpty bool
+	format    string
+}
+
+// structFieldsCache caches the fields of already inspected struct types.
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the column bindings of the given struct type.
+//
+// Fields are bound with the `csv` tag, for instance `csv:"first_name"`.
+// Untagged exported fields are bound to a column named after the field, fields tagged with `csv:"-"` are ignored.
+// Embedded structs without tag have their fields promoted.
+// Tag options are separated by a comma: `omitempty` and `format=<layout>`.
+// The format option must be the last one, everything after `format=` is the layout, commas included.
+func structFields(t reflect.Type) []structField {
+	if f, ok := structFieldsCache.Load(t); ok {
+		return f.([]structField)
+	}
+	fields := appendStructFields(nil, t, nil, "")
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func appendStructFields(fields []structField, t reflect.Type, index []int, path string) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup(tagName)
+		if tag == "-" {
+			continue
+		}
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+		p := f.Name
+		if path != "" {
+			p = path + "." + f.Name
+		}
+
+		// Promote fields of embedded structs
+		if f.Anonymous && !hasTag {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				fields = appendStructFields(fields, ft, idx, p)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// Unexported field
+			continue
+		}
+
+		sf := structField{
+			key:   f.Name,
+			path:  p,
+			index: idx,
+			typ:   f.Type,
+		}
+		if hasTag {
+			// The format option is the last one so that its layout may contain commas
+			if i := strings.Index(tag, ",format="); i >= 0 {
+				sf.format = tag[i+len(",format="):]
+				tag = tag[:i]
+			}
+			opts := strings.Split(tag, ",")
+			if opts[0] != "" {
+				sf.key = opts[0]
+			}
+			for _, o := range opts[1:] {
+				if o == "omitempty" {
+					sf.omitEmpty = true
+				}
+			}
+		}
+		fields = append(fields, sf)
+	}
+	return fields
+}
+
+// unmarshal sets the fields of the struct pointed by v with the values of this record.
+//
+// Conversions are done with the record getters (GetBool, GetInt64, GetTime...),
+// thus ErrUnknownKey and ErrWrongType are returned, wrapped with the path of the struct field.
+func (r *Record) unmarshal(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into %T, expecting a non-nil pointer to a struct", v)
+	}
+	rv = rv.Elem()
+	for _, sf := range structFields(rv.Type()) {
+		fv, err := fieldByIndex(rv, sf.index)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal field '%s': %w", sf.path, err)
+		}
+		if err := r.setField(fv, sf); err != nil {
+			return fmt.Errorf("cannot unmarshal field '%s': %w", sf.path, err)
+		}
+	}
+	return nil
+}
+
+// structHeader returns the column names bound to the fields of the given struct or pointer to struct.
+func structHeader(v interface{}) ([]string, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot derive header from %T, expecting a struct", v)
+	}
+	fields := structFields(t)
+	header := make([]string, 0, len(fields))
+	for _, sf := range fields {
+		header = append(header, sf.key)
+	}
+	return header, nil
+}
+
+// marshal returns a new Record holding the fields of the given struct or pointer to struct.
+//
+// Fields with the `omitempty` option and a zero value are not set in the record
+// so the Writer default value or EmptyValue is used instead. Other nil pointers are set as nil.
+// The `format` option sets a TimeFormatter for time.Time fields and a StringFormatter for the others.
+// Values implementing encoding.TextMarshaler are set with their text representation.
+func marshal(v interface{}) (*Record, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal %T, expecting a struct", v)
+	}
+
+	record := NewRecord()
+	for _, sf := range structFields(rv.Type()) {
+		fv, ok := lookupFieldByIndex(rv, sf.index)
+		if !ok {
+			// Embedded nil pointer
+			continue
+		}
+		if sf.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				// Written as the Writer NullValue
+				record.Set(sf.key, nil)
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		value := fv.Interface()
+		if fv.Type() != timeType && fv.Type().Implements(textMarshalerType) {
+			text, err := value.(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, fmt.Errorf("cannot marshal field '%s': %w", sf.path, err)
+			}
+			value = string(text)
+		}
+
+		var formatters []Formatter
+		if sf.format != "" {
+			if fv.Type() == timeType {
+				formatters = append(formatters, TimeFormatter(sf.format))
+			} else {
+				formatters = append(formatters, StringFormatter(sf.format))
+			}
+		}
+		record.Set(sf.key, value, formatters...)
+	}
+	return record, nil
+}
+
+// lookupFieldByIndex returns the nested field of v, false is returned if an embedded struct pointer is nil.
+func lookupFieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndex returns the nested field of v, allocating embedded struct pointers when needed.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %v", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// setField sets the value of the given column into the struct field fv.
+func (r *Record) setField(fv reflect.Value, sf structField) error {
+	if fv.Kind() == reflect.Ptr {
+		if f, ok := r.field(sf.key); ok && r.isNull(f) {
+			fv.Set(reflect.Zero(fv.Type()))
+			return nil
+		}
+		v := reflect.New(fv.Type().Elem())
+		if err := r.setField(v.Elem(), structField{key: sf.key, typ: sf.typ.Elem(), format: sf.format}); err != nil {
+			return err
+		}
+		fv.Set(v)
+		return nil
+	}
+
+	switch fv.Type() {
+	case nullTimeType:
+		layout := sf.format
+		if layout == "" {
+			layout = defaultTimeLayout
+		}
+		t, err := r.GetNullTime(layout, sf.key)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case timeType:
+		layout := sf.format
+		if layout == "" {
+			layout = defaultTimeLayout
+		}
+		t, err := r.GetTime(layout, sf.key)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := r.GetDuration(sf.key)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	if fv.CanAddr() && fv.Addr().Type().Implements(scannerType) {
+		f, ok := r.field(sf.key)
+		if !ok {
+			return r.wrapErr(sf.key, ErrUnknownKey{key: sf.key})
+		}
+		var src interface{}
+		if !r.isNull(f) {
+			src = f.String()
+		}
+		if err := fv.Addr().Interface().(sql.Scanner).Scan(src); err != nil {
+			return r.wrapErr(sf.key, ErrWrongType{key: sf.key, err: err})
+		}
+		return nil
+	}
+
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		s, err := r.Get(sf.key)
+		if err != nil {
+			return err
+		}
+		if err := fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return r.wrapErr(sf.key, ErrWrongType{key: sf.key, err: err})
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		s, err := r.Get(sf.key)
+		if err != nil {
+			return err
+		}
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := r.GetBool(sf.key)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := r.GetInt64(sf.key)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(i) {
+			return r.wrapErr(sf.key, ErrWrongType{key: sf.key, err: fmt.Errorf("%d overflows %v", i, fv.Type())})
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := r.GetUint64(sf.key)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowUint(u) {
+			return r.wrapErr(sf.key, ErrWrongType{key: sf.key, err: fmt.Errorf("%d overflows %v", u, fv.Type())})
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := r.GetFloat64(sf.key)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowFloat(f) {
+			return r.wrapErr(sf.key, ErrWrongType{key: sf.key, err: fmt.Errorf("%v overflows %v", f, fv.Type())})
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", fv.Type())
+	}
+	return nil
+}
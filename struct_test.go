@@ -0,0 +1,389 @@
+package csvhandler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tstBase struct {
+	ID string `csv:"id"`
+}
+
+type tstUpperText string
+
+func (t *tstUpperText) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		return fmt.Errorf("empty text")
+	}
+	*t = tstUpperText(strings.ToUpper(string(b)))
+	return nil
+}
+
+type tstPerson struct {
+	tstBase
+	FirstName      string        `csv:"first_name"`
+	LastName       tstUpperText  `csv:"last_name"`
+	Age            uint8         `csv:"age"`
+	IsActive       *bool         `csv:"is_active"`
+	Registered     time.Time     `csv:"registered,format=2006-01-02 15:04:05"`
+	Balance        float32       `csv:"balance"`
+	MeanConnection time.Duration `csv:"mean_connection"`
+	Ignored        string        `csv:"-"`
+	unexported     string
+}
+
+func TestReadInto(t *testing.T) {
+	f, err := os.Open(filepath.Join("tstdata", "regular.csv"))
+	require.NoError(t, err)
+	defer f.Close()
+	reader, err := NewReader(csv.NewReader(f))
+	require.NoError(t, err)
+
+	var p tstPerson
+	require.NoError(t, reader.ReadInto(&p))
+	assert.Equal(t, "cb846443-97b2-4b1a-ad62-682c99b70604", p.ID)
+	assert.Equal(t, "Holly", p.FirstName)
+	assert.Equal(t, tstUpperText("FRANKLIN"), p.LastName)
+	assert.Equal(t, uint8(27), p.Age)
+	require.NotNil(t, p.IsActive)
+	assert.True(t, *p.IsActive)
+	assert.Equal(t, time.Date(2018, 11, 5, 12, 55, 10, 0, time.UTC), p.Registered)
+	assert.Equal(t, float32(100.5), p.Balance)
+	assert.Equal(t, 12*time.Minute+10*time.Second, p.MeanConnection)
+	assert.Empty(t, p.Ignored)
+	assert.Empty(t, p.unexported)
+}
+
+func TestFormatWithComma(t *testing.T) {
+	type event struct {
+		Name string    `csv:"name"`
+		When time.Time `csv:"when,omitempty,format=Mon, 02 Jan 2006 15:04:05 MST"`
+	}
+	when := time.Date(2021, 1, 30, 10, 20, 8, 0, time.UTC)
+
+	var b bytes.Buffer
+	w, err := NewWriterFor(csv.NewWriter(&b), event{})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteHeader())
+	require.NoError(t, w.WriteStruct(event{Name: "launch", When: when}))
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "name,when\nlaunch,\"Sat, 30 Jan 2021 10:20:08 UTC\"\n", b.String())
+
+	reader, err := NewReader(csv.NewReader(&b))
+	require.NoError(t, err)
+	var e event
+	require.NoError(t, reader.ReadInto(&e))
+	assert.Equal(t, "launch", e.Name)
+	assert.True(t, when.Equal(e.When))
+}
+
+func TestReadIntoErrors(t *testing.T) {
+	testcases := map[string]struct {
+		data    string
+		dst     interface{}
+		errType interface{}
+		errMsg  string
+	}{
+		"unknown key": {
+			data: "first_name\nHolly",
+			dst: &struct {
+				Age int `csv:"age"`
+			}{},
+			errType: &ErrUnknownKey{},
+			errMsg:  "'Age'",
+		},
+		"wrong type": {
+			data: "age\nabc",
+			dst: &struct {
+				Age int `csv:"age"`
+			}{},
+			errType: &ErrWrongType{},
+			errMsg:  "'Age'",
+		},
+		"overflow": {
+			data: "age\n300",
+			dst: &struct {
+				Age int8 `csv:"age"`
+			}{},
+			errType: &ErrWrongType{},
+		},
+		"text unmarshaler error": {
+			data: "last_name\n\"\"",
+			dst: &struct {
+				LastName tstUpperText `csv:"last_name"`
+			}{},
+			errType: &ErrWrongType{},
+		},
+		"unsupported type": {
+			data: "tags\na",
+			dst: &struct {
+				Tags []string `csv:"tags"`
+			}{},
+		},
+		"not a pointer": {
+			data: "age\n10",
+			dst: struct {
+				Age int `csv:"age"`
+			}{},
+		},
+		"embedded path": {
+			data:   "first_name\nHolly",
+			dst:    &tstPerson{},
+			errMsg: "'tstBase.ID'",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReader(csv.NewReader(bytes.NewBufferString(tc.data)))
+			require.NoError(t, err)
+
+			err = reader.ReadInto(tc.dst)
+			require.Error(t, err)
+			if tc.errType != nil {
+				assert.True(t, errors.As(err, tc.errType))
+			}
+			if tc.errMsg != "" {
+				assert.Contains(t, err.Error(), tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestReadIntoEOF(t *testing.T) {
+	reader, err := NewReader(csv.NewReader(bytes.NewBufferString("first_name")))
+	require.NoError(t, err)
+
+	var p tstPerson
+	assert.Equal(t, io.EOF, reader.ReadInto(&p))
+}
+
+func TestReadAllInto(t *testing.T) {
+	type person struct {
+		FirstName string `csv:"first_name"`
+		Age       int    `csv:"age"`
+	}
+
+	testcases := map[string]struct {
+		filename string
+		dst      func() interface{}
+		names    func(interface{}) []string
+		err      bool
+	}{
+		"slice of structs": {
+			filename: "regular.csv",
+			dst:      func() interface{} { return &[]person{} },
+			names: func(v interface{}) []string {
+				var names []string
+				for _, p := range *v.(*[]person) {
+					names = append(names, p.FirstName)
+				}
+				return names
+			},
+		},
+		"slice of pointers": {
+			filename: "regular.csv",
+			dst:      func() interface{} { return &[]*person{} },
+			names: func(v interface{}) []string {
+				var names []string
+				for _, p := range *v.(*[]*person) {
+					names = append(names, p.FirstName)
+				}
+				return names
+			},
+		},
+		"not a slice": {
+			filename: "regular.csv",
+			dst:      func() interface{} { return &person{} },
+			err:      true,
+		},
+		"not a slice of structs": {
+			filename: "regular.csv",
+			dst:      func() interface{} { return &[]string{} },
+			err:      true,
+		},
+		"unmarshal error": {
+			filename: "regular.csv",
+			dst: func() interface{} {
+				return &[]struct {
+					FirstName int `csv:"first_name"`
+				}{}
+			},
+			err: true,
+		},
+		"read error": {
+			filename: "compressed.tgz",
+			dst:      func() interface{} { return &[]person{} },
+			err:      true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("tstdata", tc.filename))
+			require.NoError(t, err)
+			defer f.Close()
+			reader, err := NewReader(csv.NewReader(f))
+			require.NoError(t, err)
+
+			dst := tc.dst()
+			err = reader.ReadAllInto(dst)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"Holly", "Giacobo", "Aubrie", "Kristoforo", "Jasmine"}, tc.names(dst))
+			}
+		})
+	}
+}
+
+type tstExport struct {
+	*tstBase
+	FirstName string     `csv:"first_name"`
+	Nickname  string     `csv:"nickname,omitempty"`
+	Age       *int       `csv:"age"`
+	Balance   float64    `csv:"balance,format=%.2f"`
+	Updated   time.Time  `csv:"updated,format=2006-01-02"`
+	Deleted   *time.Time `csv:"deleted,format=2006-01-02"`
+	Level     tstLevel   `csv:"level"`
+	Ignored   string     `csv:"-"`
+}
+
+type tstLevel int
+
+func (l tstLevel) MarshalText() ([]byte, error) {
+	if l < 0 {
+		return nil, fmt.Errorf("negative level")
+	}
+	return []byte(strings.Repeat("*", int(l))), nil
+}
+
+func TestNewWriterFor(t *testing.T) {
+	testcases := map[string]struct {
+		sample   interface{}
+		expected []string
+		err      bool
+	}{
+		"struct": {
+			sample:   tstExport{},
+			expected: []string{"id", "first_name", "nickname", "age", "balance", "updated", "deleted", "level"},
+		},
+		"pointer to struct": {
+			sample:   &tstExport{},
+			expected: []string{"id", "first_name", "nickname", "age", "balance", "updated", "deleted", "level"},
+		},
+		"not a struct": {
+			sample: "foo",
+			err:    true,
+		},
+		"nil": {
+			err: true,
+		},
+		"duplicate key": {
+			sample: struct {
+				A string `csv:"a"`
+				B string `csv:"a"`
+			}{},
+			err: true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			w, err := NewWriterFor(csv.NewWriter(nil), tc.sample)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, w.header)
+			}
+		})
+	}
+}
+
+func TestWriteStruct(t *testing.T) {
+	age := 27
+	deleted := time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2021, 1, 26, 10, 20, 8, 0, time.UTC)
+
+	testcases := map[string]struct {
+		value     interface{}
+		defaults  map[string]interface{}
+		nullValue string
+		expected  string
+		err       bool
+	}{
+		"regular": {
+			value: tstExport{
+				tstBase:   &tstBase{ID: "42"},
+				FirstName: "Holly",
+				Nickname:  "Ho",
+				Age:       &age,
+				Balance:   100.5,
+				Updated:   updated,
+				Deleted:   &deleted,
+				Level:     3,
+			},
+			expected: "42,Holly,Ho,27,100.50,2021-01-26,2021-02-03,***\n",
+		},
+		"empty and default": {
+			value: &tstExport{
+				FirstName: "Holly",
+				Updated:   updated,
+			},
+			defaults: map[string]interface{}{
+				"nickname": "none",
+				"age":      18,
+			},
+			expected: ",Holly,none,,0.00,2021-01-26,,\n",
+		},
+		"null value": {
+			value: &tstExport{
+				FirstName: "Holly",
+				Updated:   updated,
+			},
+			nullValue: "NULL",
+			expected:  ",Holly,,NULL,0.00,2021-01-26,NULL,\n",
+		},
+		"marshal text error": {
+			value: tstExport{Level: -1},
+			err:   true,
+		},
+		"not a struct": {
+			value: 10,
+			err:   true,
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriterFor(csv.NewWriter(&b), tstExport{})
+			require.NoError(t, err)
+			w.NullValue = tc.nullValue
+			for k, v := range tc.defaults {
+				w.SetDefault(k, v)
+			}
+
+			err = w.WriteStruct(tc.value)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package csvhandler
+
+import (
+	"io"
+	"reflect"
+)
+
+// TypedReader reads records into values of type T, a struct or a pointer to a struct, see Reader.ReadInto.
+type TypedReader[T any] struct {
+	reader *Reader
+}
+
+// NewTypedReader creates a new TypedReader reading from the given Reader.
+func NewTypedReader[T any](r *Reader) *TypedReader[T] {
+	return &TypedReader[T]{reader: r}
+}
+
+// Read reads the next record into a new value. Errors are the same as Reader.ReadInto.
+//
+// If MaxErrors is set, records with a parse error or that cannot be stored in a value are rejected
+// and the next one is read instead, as for ReadAll and All, see Reader.Rejected.
+func (r *TypedReader[T]) Read() (T, error) {
+	for {
+		record, raw, err := r.reader.next()
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		v, err := r.unmarshal(record)
+		if err == nil {
+			return v, nil
+		}
+		if err := r.reader.rejectRecord(record, raw, err); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// unmarshal returns a new value holding the given record.
+func (r *TypedReader[T]) unmarshal(record *Record) (T, error) {
+	var v T
+	if rv := reflect.ValueOf(&v).Elem(); rv.Kind() == reflect.Ptr {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		return v, record.unmarshal(v)
+	}
+	err := record.unmarshal(&v)
+	return v, err
+}
+
+// ReadAll reads all the remaining records, see Reader.ReadAllInto.
+func (r *TypedReader[T]) ReadAll() ([]T, error) {
+	var values []T
+	if err := r.reader.ReadAllInto(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// All returns an iterator over the remaining records, to be used with a `for range` loop from Go 1.23:
+//
+//	for person, err := range reader.All() {
+//		...
+//	}
+//
+// Iteration stops at the end of the input or after the first error, rejected records are skipped as with Read.
+func (r *TypedReader[T]) All() func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		for {
+			v, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// TypedWriter writes values of type T, a struct or a pointer to a struct, see Writer.WriteStruct.
+type TypedWriter[T any] struct {
+	writer *Writer
+}
+
+// NewTypedWriter creates a new TypedWriter writing to the given Writer, for instance created with NewWriterFor.
+func NewTypedWriter[T any](w *Writer) *TypedWriter[T] {
+	return &TypedWriter[T]{writer: w}
+}
+
+// Write writes the given value as a new line, see Writer.WriteStruct.
+func (w *TypedWriter[T]) Write(v T) error {
+	return w.writer.WriteStruct(v)
+}
+
+// WriteAll writes all the given values then flushes.
+func (w *TypedWriter[T]) WriteAll(values []T) error {
+	for _, v := range values {
+		if err := w.writer.WriteStruct(v); err != nil {
+			return err
+		}
+	}
+	return w.writer.Flush()
+}
+
+// Get returns the value of the given key converted to T.
+//
+// Conversions are the same as for struct fields (see Reader.ReadInto): the record getters are used for
+// string, bool, integers, floats, time.Duration and time.Time (RFC3339 layout), as well as encoding.TextUnmarshaler.
+// Pointer types are allocated.
+func Get[T any](r *Record, key string) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if err := r.setField(rv, structField{key: key, typ: rv.Type()}); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
@@ -0,0 +1,197 @@
+package csvhandler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tstName struct {
+	FirstName string `csv:"first_name"`
+	Age       int    `csv:"age"`
+}
+
+func newTstTypedReader[T any](t *testing.T, filename string) *TypedReader[T] {
+	f, err := os.Open(filepath.Join("tstdata", filename))
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	reader, err := NewReader(csv.NewReader(f))
+	require.NoError(t, err)
+	return NewTypedReader[T](reader)
+}
+
+func TestTypedReaderRead(t *testing.T) {
+	r := newTstTypedReader[tstName](t, "regular.csv")
+	p, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, tstName{FirstName: "Holly", Age: 27}, p)
+
+	rp := newTstTypedReader[*tstPerson](t, "regular.csv")
+	pp, err := rp.Read()
+	require.NoError(t, err)
+	require.NotNil(t, pp)
+	assert.Equal(t, "Holly", pp.FirstName)
+	assert.Equal(t, tstUpperText("FRANKLIN"), pp.LastName)
+}
+
+func TestTypedReaderReadAll(t *testing.T) {
+	r := newTstTypedReader[tstName](t, "regular.csv")
+	all, err := r.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, all, 5)
+	assert.Equal(t, "Jasmine", all[4].FirstName)
+
+	_, err = newTstTypedReader[struct {
+		FirstName int `csv:"first_name"`
+	}](t, "regular.csv").ReadAll()
+	assert.Error(t, err)
+}
+
+func TestTypedReaderAll(t *testing.T) {
+	var names []string
+	all := newTstTypedReader[tstName](t, "regular.csv").All()
+	all(func(p tstName, err error) bool {
+		require.NoError(t, err)
+		names = append(names, p.FirstName)
+		return true
+	})
+	assert.Equal(t, []string{"Holly", "Giacobo", "Aubrie", "Kristoforo", "Jasmine"}, names)
+
+	// Early break
+	names = nil
+	newTstTypedReader[tstName](t, "regular.csv").All()(func(p tstName, err error) bool {
+		names = append(names, p.FirstName)
+		return len(names) < 2
+	})
+	assert.Equal(t, []string{"Holly", "Giacobo"}, names)
+
+	// Stops after first error
+	var errs int
+	newTstTypedReader[struct {
+		FirstName int `csv:"first_name"`
+	}](t, "regular.csv").All()(func(_ struct {
+		FirstName int `csv:"first_name"`
+	}, err error) bool {
+		assert.Error(t, err)
+		errs++
+		return true
+	})
+	assert.Equal(t, 1, errs)
+}
+
+func TestTypedReaderRejects(t *testing.T) {
+	data := "first_name,age\nHolly,27\nJohn,abc\nJane\nAubrie,31\n"
+	testcases := map[string]func(r *TypedReader[tstName]) ([]tstName, error){
+		"read": func(r *TypedReader[tstName]) ([]tstName, error) {
+			var values []tstName
+			for {
+				v, err := r.Read()
+				if err == io.EOF {
+					return values, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, v)
+			}
+		},
+		"read all": func(r *TypedReader[tstName]) ([]tstName, error) {
+			return r.ReadAll()
+		},
+		"all": func(r *TypedReader[tstName]) ([]tstName, error) {
+			var values []tstName
+			var err error
+			r.All()(func(v tstName, e error) bool {
+				if e != nil {
+					err = e
+					return false
+				}
+				values = append(values, v)
+				return true
+			})
+			return values, err
+		},
+	}
+
+	for n, read := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReader(csv.NewReader(bytes.NewBufferString(data)))
+			require.NoError(t, err)
+			reader.MaxErrors = 5
+
+			values, err := read(NewTypedReader[tstName](reader))
+			require.NoError(t, err)
+			assert.Equal(t, []tstName{{FirstName: "Holly", Age: 27}, {FirstName: "Aubrie", Age: 31}}, values)
+			rejected := reader.Rejected()
+			require.Len(t, rejected, 2)
+			assert.Equal(t, 3, rejected[0].Line)
+			assert.Equal(t, 4, rejected[1].Line)
+		})
+	}
+}
+
+func TestTypedWriter(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriterFor(csv.NewWriter(&b), tstName{})
+	require.NoError(t, err)
+	tw := NewTypedWriter[tstName](w)
+	require.NoError(t, w.WriteHeader())
+	require.NoError(t, tw.Write(tstName{FirstName: "Holly", Age: 27}))
+	require.NoError(t, tw.WriteAll([]tstName{{FirstName: "Giacobo", Age: 20}, {FirstName: "Aubrie"}}))
+	assert.Equal(t, "first_name,age\nHolly,27\nGiacobo,20\nAubrie,0\n", b.String())
+
+	pw := NewTypedWriter[*tstName](w)
+	assert.Error(t, pw.WriteAll([]*tstName{nil}))
+}
+
+func TestGetGeneric(t *testing.T) {
+	r := NewRecord()
+	r.Set("name", "Holly")
+	r.Set("age", "27")
+	r.Set("active", "true")
+	r.Set("balance", "100.5")
+	r.Set("registered", "2018-11-05T12:55:10Z")
+	r.Set("connection", "12m10s")
+	r.Set("last_name", "franklin")
+
+	name, err := Get[string](r, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "Holly", name)
+	age, err := Get[int](r, "age")
+	require.NoError(t, err)
+	assert.Equal(t, 27, age)
+	small, err := Get[uint8](r, "age")
+	require.NoError(t, err)
+	assert.Equal(t, uint8(27), small)
+	active, err := Get[*bool](r, "active")
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.True(t, *active)
+	balance, err := Get[float64](r, "balance")
+	require.NoError(t, err)
+	assert.Equal(t, 100.5, balance)
+	registered, err := Get[time.Time](r, "registered")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 11, 5, 12, 55, 10, 0, time.UTC), registered)
+	connection, err := Get[time.Duration](r, "connection")
+	require.NoError(t, err)
+	assert.Equal(t, 12*time.Minute+10*time.Second, connection)
+	lastName, err := Get[tstUpperText](r, "last_name")
+	require.NoError(t, err)
+	assert.Equal(t, tstUpperText("FRANKLIN"), lastName)
+
+	_, err = Get[int](r, "name")
+	assert.True(t, errors.As(err, &ErrWrongType{}))
+	_, err = Get[int](r, "unknown")
+	assert.True(t, errors.As(err, &ErrUnknownKey{}))
+	_, err = Get[[]string](r, "name")
+	assert.Error(t, err)
+}
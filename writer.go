@@ -3,7 +3,9 @@ package csvhandler
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 )
 
 const defaultEmptyValue = ""
@@ -11,19 +13,66 @@ const defaultEmptyValue = ""
 // A Writer writes records using CSV encoding.
 //
 // It internally uses a `encoding/csv.Writer` to write the records.
+//
+// By default, lines are flushed after each write. For large outputs, the Writer can be buffered
+// by setting FlushRows, FlushBytes and FlushInterval: lines are flushed once one of the thresholds is reached,
+// with Flush or with Close. The first write error is kept and returned by all subsequent calls.
 type Writer struct {
-	writer     *csv.Writer
+	writer     lineWriter
 	header     []string
+	columns    map[string]struct{} // Keys of the header
 	defaults   map[string]field
 	formatters map[string]Formatter
+	formatter  Formatter                   // Formatter used for values without formatter
+	quote      func(formatted string) bool // Whether a formatted field must be forced as text, see lineWriter
+	exempt     map[string]struct{}         // Columns exempt from the formula policy
 	mutex      *sync.Mutex
+	rows       int       // Number of lines written since last flush
+	bytes      int       // Approximate size of the lines written since last flush
+	flushed    time.Time // Time of last flush
+	err        error     // First write error
+	closed     bool
+	closer     io.Closer      // Closed by Close, if any
+	headerDone bool           // Whether the header line has been written
+	count      int            // Number of records written
+	dynamic    *dynamicHeader // Records waiting for the header to be built, see NewDynamicWriter
 	EmptyValue string
+	NullValue  string        // Value written for null fields, that is nil values, nil pointers or null driver.Valuer, empty by default
+	AutoHeader bool          // Whether the header line is written before the first record, if not already written
+	StrictKeys bool          // Whether Write returns ErrUnknownKey if the record has keys not in header
+	RequireAll bool          // Whether Write returns ErrMissingColumns if a column has no value in the record nor default
+	Formulas   FormulaPolicy // How values that would be interpreted as formulas are handled, FormulaAllow by default
+
+	FlushRows     int           // Number of lines after which lines are flushed, 1 by default, 0 to disable
+	FlushBytes    int           // Approximate size of the lines after which lines are flushed, 0 to disable
+	FlushInterval time.Duration // Duration since last flush after which lines are flushed on next write, 0 to disable
+}
+
+// lineWriter writes the lines of a Writer.
+type lineWriter interface {
+	// Write writes a line, fields whose quote flag is true are forced as text, for instance as `="00123"` for Excel.
+	Write(fields []string, quote []bool) error
+	Flush()
+	Error() error
+}
+
+// csvLineWriter is the lineWriter based on an `encoding/csv.Writer`, which quotes fields only when needed.
+type csvLineWriter struct {
+	*csv.Writer
+}
+
+func (w csvLineWriter) Write(fields []string, _ []bool) error {
+	return w.Writer.Write(fields)
 }
 
 // NewWriter creates a new Writer from the given `encoding/csv.Wrtiter` and header.
 //
 // If a duplicate is detected among column names, ErrDuplicateKey is returned.
 func NewWriter(w *csv.Writer, header ...string) (*Writer, error) {
+	return newWriter(csvLineWriter{w}, header)
+}
+
+func newWriter(w lineWriter, header []string) (*Writer, error) {
 	// Check for duplicates in header
 	set := make(map[string]struct{})
 	for _, h := range header {
@@ -36,13 +85,43 @@ func NewWriter(w *csv.Writer, header ...string) (*Writer, error) {
 	return &Writer{
 		writer:     w,
 		header:     header,
+		columns:    set,
 		defaults:   make(map[string]field),
 		formatters: make(map[string]Formatter),
+		formatter:  defaultFormatter,
+		exempt:     make(map[string]struct{}),
 		mutex:      &sync.Mutex{},
+		flushed:    time.Now(),
+		FlushRows:  1,
 		EmptyValue: defaultEmptyValue,
 	}, nil
 }
 
+// NewWriterFor creates a new Writer from the given `encoding/csv.Writer` with a header derived from the given struct.
+//
+// Sample is a struct, or a pointer to a struct, whose `csv` struct tags define the columns, see WriteStruct.
+// Header keys are in the same order as the struct fields.
+//
+// If a duplicate is detected among column names, ErrDuplicateKey is returned.
+func NewWriterFor(w *csv.Writer, sample interface{}) (*Writer, error) {
+	header, err := structHeader(sample)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(w, header...)
+}
+
+// Header returns the column names of this Writer.
+// For a dynamic Writer, it is empty until the header is built, see NewDynamicWriter.
+func (w *Writer) Header() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	header := make([]string, len(w.header))
+	copy(header, w.header)
+	return header
+}
+
 // SetDefault sets the default value to be used if there is no value for this key in the record.
 //
 // If the defined value is nil, default value is used.
@@ -74,19 +153,26 @@ func (w *Writer) SetFormatter(key string, formatter ...Formatter) {
 //
 // Field delimiter used is the one specified in the `encoding/csv.Writer` given when creating this Writer.
 // Header keys are written in the same order as specified in `NewWriter` function.
+// The header line is written only once, subsequent calls do nothing.
 func (w *Writer) WriteHeader() error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
+	return w.writeHeader()
+}
+
+// writeHeader writes the header line if not already written. Caller must hold the mutex.
+func (w *Writer) writeHeader() error {
+	if w.headerDone || w.dynamic != nil {
+		// Dynamic header is written once built
+		return nil
+	}
 	if len(w.header) != 0 {
-		if err := w.writer.Write(w.header); err != nil {
+		if err := w.writeLine(w.header, nil); err != nil {
 			return fmt.Errorf("cannot write header line: %s", err)
 		}
 	}
-	w.writer.Flush()
-	if err := w.writer.Error(); err != nil {
-		return fmt.Errorf("cannot write header line: %s", err)
-	}
+	w.headerDone = true
 	return nil
 }
 
@@ -95,49 +181,234 @@ func (w *Writer) WriteHeader() error {
 // Field delimiter used is the one specified in the `encoding/csv.Writer` given when creating this Writer.
 // Fields are written in the header order specified in `NewWriter` function.
 // If field is not specified in the record, a specified default value (see function SetDefault())
-// 	can be used, otherwise EmptyValue is used.
-// Fields with key not in header will be ignored.
+// can be used, otherwise EmptyValue is used.
+// Formatted values are then checked against the Formulas policy.
+// Fields with key not in header will be ignored, unless StrictKeys is set.
+// If RequireAll is set, every column must have a value in the record or a default value.
+// If AutoHeader is set, the header line is written first if not already done.
 func (w *Writer) Write(r *Record) error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
-	record := make([]string, 0, len(w.header))
+	var err error
+	if w.dynamic != nil {
+		err = w.writeDynamic(r)
+	} else {
+		err = w.write(r)
+	}
+	if err == nil {
+		w.count++
+	}
+	return err
+}
 
+// write writes the given record, see Write. Caller must hold the mutex.
+func (w *Writer) write(r *Record) error {
+	if err := w.checkKeys(r); err != nil {
+		return err
+	}
+	if w.AutoHeader {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, 0, len(w.header))
+	quote := make([]bool, 0, len(w.header))
 	for _, h := range w.header {
-		value, err := w.getFormattedValue(r, h)
+		value, q, err := w.formatField(r, h)
 		if err != nil {
 			return err
 		}
 		record = append(record, value)
+		quote = append(quote, q)
 	}
 
-	if err := w.writer.Write(record); err != nil {
+	if err := w.writeLine(record, quote); err != nil {
 		return fmt.Errorf("cannot write record: %s", err)
 	}
+	return nil
+}
+
+// checkKeys checks the keys of the given record against the header according to StrictKeys and RequireAll.
+// Columns are resolved as the record keys, that is with the normalizers and aliases of the Reader for read records.
+func (w *Writer) checkKeys(r *Record) error {
+	if w.StrictKeys {
+		columns := w.columns
+		if r.layout != nil {
+			columns = make(map[string]struct{}, len(w.header))
+			for _, h := range w.header {
+				columns[r.layout.key(h)] = struct{}{}
+			}
+		}
+		var unknown []string
+		for _, k := range r.keys {
+			if _, ok := columns[k]; !ok {
+				unknown = append(unknown, k)
+			}
+		}
+		if len(unknown) > 0 {
+			return ErrUnknownKey{key: unknown[0], keys: unknown}
+		}
+	}
+	if w.RequireAll {
+		var missing []string
+		for _, h := range w.header {
+			_, hasField := r.field(h)
+			_, hasDefault := w.defaults[h]
+			if !hasField && !hasDefault {
+				missing = append(missing, h)
+			}
+		}
+		if len(missing) > 0 {
+			return ErrMissingColumns{keys: missing}
+		}
+	}
+	return nil
+}
+
+// formatField returns the formatted value of the given record and column, checked against the Formulas policy,
+// and whether it must be quoted.
+func (w *Writer) formatField(r *Record, column string) (string, bool, error) {
+	v, f := w.getValue(r, column)
+	value, err := f(v)
+	if err != nil {
+		return "", false, err
+	}
+	if value, err = w.checkFormula(column, value); err != nil {
+		return "", false, err
+	}
+	return value, w.quote != nil && w.quote(value), nil
+}
+
+// Flush writes the buffered lines to the underlying writer.
+//
+// For a dynamic Writer without spooling, the header is built from the records received so far if not already done,
+// nothing is written if no record has been received yet.
+func (w *Writer) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.dynamic != nil && !w.dynamic.opts.Spool && len(w.dynamic.records) > 0 {
+		if err := w.buildHeader(); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+// Close flushes the buffered lines and closes the file opened by OpenAppend, if any.
+// For a dynamic Writer, the header is built and written with the pending records first if not already done.
+// The Writer cannot be used afterwards.
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return w.err
+	}
+	var err error
+	if w.dynamic != nil {
+		err = w.buildHeader()
+	}
+	if e := w.flush(); e != nil && err == nil {
+		err = e
+	}
+	w.closed = true
+	if w.closer != nil {
+		if e := w.closer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// writeLine writes the given fields as a new line then flushes if a threshold is reached.
+// Caller must hold the mutex.
+func (w *Writer) writeLine(fields []string, quote []bool) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.closed {
+		return fmt.Errorf("writer is closed")
+	}
+	if err := w.writer.Write(fields, quote); err != nil {
+		w.err = err
+		return err
+	}
+
+	w.rows++
+	for _, f := range fields {
+		w.bytes += len(f) + 1
+	}
+	if w.FlushRows > 0 && w.rows >= w.FlushRows ||
+		w.FlushBytes > 0 && w.bytes >= w.FlushBytes ||
+		w.FlushInterval > 0 && time.Since(w.flushed) >= w.FlushInterval {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered lines and resets the thresholds. Caller must hold the mutex.
+func (w *Writer) flush() error {
+	if w.err != nil {
+		return w.err
+	}
 	w.writer.Flush()
+	w.rows, w.bytes, w.flushed = 0, 0, time.Now()
 	if err := w.writer.Error(); err != nil {
-		return fmt.Errorf("cannot write record: %s", err)
+		w.err = err
+		return err
 	}
 	return nil
 }
 
+// WriteStruct writes the given struct, or pointer to struct, as a new line.
+//
+// Struct fields are matched to columns with the `csv` struct tag, for instance `csv:"first_name"`.
+// Untagged exported fields are matched using the field name and fields tagged with `csv:"-"` are ignored.
+// Tag options are:
+//   - `omitempty`: a zero value is considered as not specified, so the default value or EmptyValue is used.
+//   - `format=<layout>`: a TimeFormatter for time.Time fields, a StringFormatter otherwise.
+//     It must be the last option, the layout may contain commas.
+//
+// Nil pointers are written as NullValue, fields implementing driver.Valuer (such as sql.NullInt64) as their value.
+//
+// Values are then written as with the Write function, thus defaults and formatters defined on this Writer apply.
+func (w *Writer) WriteStruct(v interface{}) error {
+	record, err := marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.Write(record)
+}
+
 // getFormattedValue returned the formatted value of the given record and column.
 //
 // Value used is from:
 // 1. corresponding field of the record
 // 2. default value defined for the column
 // 3. Writer's EmptyValue
+// Null values (nil, nil pointers and driver.Valuer such as sql.NullInt64 whose value is nil) are replaced by NullValue,
+// which is formatted with the Writer's formatter and the column formatter, if any.
+// Other driver.Valuer are replaced by their value.
 //
 // Formatter used is from:
 // 1. associated formatter to the field or defaultValue depending on the value used
-// 2. defaultFormatter if both are missing
+// 2. Writer's formatter (defaultFormatter unless changed by a profile) if both are missing
 // 3. formatter defined for column is chained if specified
 func (w *Writer) getFormattedValue(record *Record, column string) (string, error) {
+	v, f := w.getValue(record, column)
+	return f(v)
+}
+
+// getValue returns the value of the given record and column with the formatter to use, see getFormattedValue.
+func (w *Writer) getValue(record *Record, column string) (interface{}, Formatter) {
 	var f Formatter
 	var v interface{}
 	// Use EmptyValue if record has no field and no defaultValue is set
 	v = w.EmptyValue
-	if field, hasField := record.fields[column]; hasField {
+	if field, hasField := record.field(column); hasField {
 		v = field.value
 		f = field.formatter
 	} else if defValue, hasDefault := w.defaults[column]; hasDefault {
@@ -145,9 +416,19 @@ func (w *Writer) getFormattedValue(record *Record, column string) (string, error
 		f = defValue.formatter
 	}
 
+	v, err := nullValue(v)
+	if err != nil {
+		return nil, func(interface{}) (string, error) {
+			return "", err
+		}
+	}
+	if v == nil {
+		v, f = w.NullValue, nil
+	}
+
 	if f == nil {
-		// No formatter defined at all, fallback to defaultFormatter
-		f = defaultFormatter
+		// No formatter defined at all, fallback to Writer's formatter
+		f = w.formatter
 	}
 
 	// Finally, check for column formatter, if present chain with field formatter
@@ -155,16 +436,15 @@ func (w *Writer) getFormattedValue(record *Record, column string) (string, error
 		f = chainFormatter(f, formatter)
 	}
 
-	return f(v)
+	return v, f
 }
 
-// WriteAll writes all the given records using the Write function.
+// WriteAll writes all the given records using the Write function, then flushes.
 func (w *Writer) WriteAll(r []*Record) error {
 	for _, record := range r {
 		if err := w.Write(record); err != nil {
 			return err
 		}
 	}
-	w.writer.Flush()
-	return w.writer.Error()
+	return w.Flush()
 }
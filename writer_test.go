@@ -6,7 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -115,6 +119,78 @@ func TestWriteHeader(t *testing.T) {
 	}
 }
 
+func TestWriteHeaderOnce(t *testing.T) {
+	testcases := map[string]struct {
+		autoHeader bool
+		header     bool // Whether WriteHeader is called before writing
+		expected   string
+	}{
+		"auto header": {
+			autoHeader: true,
+			expected:   "first_name\nHolly\nGiacobo\n",
+		},
+		"auto header already written": {
+			autoHeader: true,
+			header:     true,
+			expected:   "first_name\nHolly\nGiacobo\n",
+		},
+		"header": {
+			header:   true,
+			expected: "first_name\nHolly\nGiacobo\n",
+		},
+		"no header": {
+			expected: "Holly\nGiacobo\n",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(csv.NewWriter(&b), "first_name")
+			require.NoError(t, err)
+			w.AutoHeader = tc.autoHeader
+			if tc.header {
+				require.NoError(t, w.WriteHeader())
+				require.NoError(t, w.WriteHeader())
+			}
+
+			var records []*Record
+			for _, name := range []string{"Holly", "Giacobo"} {
+				r := NewRecord()
+				r.Set("first_name", name)
+				records = append(records, r)
+			}
+			require.NoError(t, w.WriteAll(records))
+			if tc.autoHeader || tc.header {
+				// Header already written
+				require.NoError(t, w.WriteHeader())
+			}
+			assert.Equal(t, tc.expected, b.String())
+		})
+	}
+}
+
+func TestWriteHeaderConcurrent(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(csv.NewWriter(&b), "first_name")
+	require.NoError(t, err)
+	w.AutoHeader = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := NewRecord()
+			r.Set("first_name", "Holly")
+			assert.NoError(t, w.WriteHeader())
+			assert.NoError(t, w.Write(r))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "first_name\n"+strings.Repeat("Holly\n", 10), b.String())
+}
+
 func TestWrite(t *testing.T) {
 	testcases := map[string]struct {
 		values    map[string]field
@@ -271,6 +347,232 @@ func TestWriteAll(t *testing.T) {
 	}
 }
 
+func TestWriteBuffered(t *testing.T) {
+	testcases := map[string]struct {
+		rows     int
+		bytes    int
+		interval time.Duration
+		flushed  []int // Number of lines flushed after each write
+	}{
+		"per row": {
+			rows:    1,
+			flushed: []int{1, 2, 3, 4},
+		},
+		"rows": {
+			rows:    3,
+			flushed: []int{0, 0, 3, 3},
+		},
+		"bytes": {
+			rows:    0,
+			bytes:   20,
+			flushed: []int{0, 2, 2, 4},
+		},
+		"interval": {
+			rows:     0,
+			interval: time.Nanosecond,
+			flushed:  []int{1, 2, 3, 4},
+		},
+		"no threshold": {
+			rows:    0,
+			flushed: []int{0, 0, 0, 0},
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(csv.NewWriter(&b), "first_name", "last_name")
+			require.NoError(t, err)
+			w.FlushRows = tc.rows
+			w.FlushBytes = tc.bytes
+			w.FlushInterval = tc.interval
+
+			for i, expected := range tc.flushed {
+				r := NewRecord()
+				r.Set("first_name", "Holly")
+				r.Set("last_name", "Franklin")
+				require.NoError(t, w.Write(r))
+				assert.Equal(t, expected, strings.Count(b.String(), "\n"), "write %d", i)
+			}
+			require.NoError(t, w.Close())
+			assert.Equal(t, len(tc.flushed), strings.Count(b.String(), "\n"))
+		})
+	}
+}
+
+func TestWriteFirstError(t *testing.T) {
+	tw := &tstFailingWriter{}
+	w, err := NewWriter(csv.NewWriter(tw), "first_name")
+	require.NoError(t, err)
+	w.FlushRows = 0
+
+	r := NewRecord()
+	r.Set("first_name", "Holly")
+	require.NoError(t, w.Write(r))
+	tw.err = fmt.Errorf("disk full")
+	err = w.Flush()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+
+	// Following errors are not returned, the first one is kept
+	tw.err = fmt.Errorf("other error")
+	err = w.Write(r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+	err = w.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+}
+
+func TestWriteClosed(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(csv.NewWriter(&b), "first_name")
+	require.NoError(t, err)
+	w.FlushRows = 0
+	require.NoError(t, w.WriteHeader())
+	assert.Empty(t, b.String())
+
+	require.NoError(t, w.Close())
+	assert.Equal(t, "first_name\n", b.String())
+	require.NoError(t, w.Close())
+	assert.Error(t, w.Write(NewRecord()))
+}
+
+// tstFailingWriter is a writer whose error can be changed between calls
+type tstFailingWriter struct {
+	err error
+}
+
+func (t *tstFailingWriter) Write(p []byte) (n int, err error) {
+	if t.err != nil {
+		return 0, t.err
+	}
+	return len(p), nil
+}
+
+func TestWriteStrict(t *testing.T) {
+	testcases := map[string]struct {
+		strictKeys bool
+		requireAll bool
+		values     map[string]interface{}
+		expected   string
+		errType    interface{}
+		errKeys    []string
+	}{
+		"lenient": {
+			values: map[string]interface{}{
+				"frist_name": "Holly",
+				"age":        27,
+			},
+			expected: ",Smith,27\n",
+		},
+		"unknown keys": {
+			strictKeys: true,
+			values: map[string]interface{}{
+				"frist_name": "Holly",
+				"lats_name":  "Franklin",
+				"age":        27,
+			},
+			errType: &ErrUnknownKey{},
+			errKeys: []string{"frist_name", "lats_name"},
+		},
+		"missing columns": {
+			requireAll: true,
+			values: map[string]interface{}{
+				"age": 27,
+			},
+			errType: &ErrMissingColumns{},
+			errKeys: []string{"first_name"},
+		},
+		"strict": {
+			strictKeys: true,
+			requireAll: true,
+			values: map[string]interface{}{
+				"first_name": "Holly",
+				"age":        27,
+			},
+			expected: "Holly,Smith,27\n",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(csv.NewWriter(&b), "first_name", "last_name", "age")
+			require.NoError(t, err)
+			w.StrictKeys = tc.strictKeys
+			w.RequireAll = tc.requireAll
+			w.SetDefault("last_name", "Smith")
+			// Set keys in a stable order, unknown keys are listed in record order
+			keys := make([]string, 0, len(tc.values))
+			for k := range tc.values {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			r := NewRecord()
+			for _, k := range keys {
+				r.Set(k, tc.values[k])
+			}
+
+			err = w.Write(r)
+			switch e := tc.errType.(type) {
+			case nil:
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, b.String())
+			case *ErrUnknownKey:
+				require.True(t, errors.As(err, e))
+				assert.Equal(t, tc.errKeys, e.Keys())
+				assert.Empty(t, b.String())
+			case *ErrMissingColumns:
+				require.True(t, errors.As(err, e))
+				assert.Equal(t, tc.errKeys, e.Keys())
+				assert.Empty(t, b.String())
+			}
+		})
+	}
+}
+
+func TestWriteStrictReadRecord(t *testing.T) {
+	testcases := map[string]struct {
+		data     string
+		opts     []ReaderOption
+		header   []string
+		expected string
+	}{
+		"normalizer": {
+			data:     "First Name,Age\nHolly,27\n",
+			opts:     []ReaderOption{WithNormalizer(NormalizeCase, NormalizeSpaces)},
+			header:   []string{"First Name", "Age"},
+			expected: "First Name,Age\nHolly,27\n",
+		},
+		"aliases": {
+			data:     "name,mail\nHolly,holly@example.com\n",
+			opts:     []ReaderOption{WithAliases(map[string][]string{"email": {"mail"}})},
+			header:   []string{"name", "mail"},
+			expected: "name,mail\nHolly,holly@example.com\n",
+		},
+	}
+
+	for n, tc := range testcases {
+		t.Run(n, func(t *testing.T) {
+			reader, err := NewReaderWithOptions(csv.NewReader(bytes.NewBufferString(tc.data)), tc.opts...)
+			require.NoError(t, err)
+			r, err := reader.Read()
+			require.NoError(t, err)
+
+			var b bytes.Buffer
+			w, err := NewWriter(csv.NewWriter(&b), tc.header...)
+			require.NoError(t, err)
+			w.StrictKeys = true
+			w.RequireAll = true
+			require.NoError(t, w.WriteHeader())
+			require.NoError(t, w.Write(r))
+			require.NoError(t, w.Flush())
+			assert.Equal(t, tc.expected, b.String())
+		})
+	}
+}
+
 func TestGetFormattedValue(t *testing.T) {
 	tstFormatter := func(v interface{}) (string, error) {
 		return fmt.Sprintf("this is a test, %v", v), nil